@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/Infisical/infisical-merge/packages/config"
 	"github.com/go-resty/resty/v2"
@@ -544,3 +545,245 @@ func CallUpdateRawSecretsV3(httpClient *resty.Client, request UpdateRawSecretByN
 
 	return nil
 }
+
+// UnsuccessfulResponseError lets callers tell the status code of a failed request apart, e.g. to fall back to one request
+// per secret on instances that answer 404 because they predate the batch endpoints
+type UnsuccessfulResponseError struct {
+	StatusCode int
+	message    string
+}
+
+func (e *UnsuccessfulResponseError) Error() string {
+	return e.message
+}
+
+func newUnsuccessfulResponseError(caller string, response *resty.Response) error {
+	return &UnsuccessfulResponseError{
+		StatusCode: response.StatusCode(),
+		message:    fmt.Sprintf("%s: Unsuccessful response [%v %v] [status-code=%v] [response=%v]", caller, response.Request.Method, response.Request.URL, response.StatusCode(), response.String()),
+	}
+}
+
+func CallCreateRawSecretsBatchV3(httpClient *resty.Client, request BatchCreateRawSecretsV3Request) error {
+	response, err := httpClient.
+		R().
+		SetHeader("User-Agent", USER_AGENT).
+		SetBody(request).
+		Post(fmt.Sprintf("%v/v3/secrets/batch/raw", config.INFISICAL_URL))
+
+	if err != nil {
+		return fmt.Errorf("CallCreateRawSecretsBatchV3: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return newUnsuccessfulResponseError("CallCreateRawSecretsBatchV3", response)
+	}
+
+	return nil
+}
+
+func CallUpdateRawSecretsBatchV3(httpClient *resty.Client, request BatchUpdateRawSecretsV3Request) error {
+	response, err := httpClient.
+		R().
+		SetHeader("User-Agent", USER_AGENT).
+		SetBody(request).
+		Patch(fmt.Sprintf("%v/v3/secrets/batch/raw", config.INFISICAL_URL))
+
+	if err != nil {
+		return fmt.Errorf("CallUpdateRawSecretsBatchV3: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return newUnsuccessfulResponseError("CallUpdateRawSecretsBatchV3", response)
+	}
+
+	return nil
+}
+
+func CallDeleteRawSecretsBatchV3(httpClient *resty.Client, request BatchDeleteRawSecretsV3Request) error {
+	response, err := httpClient.
+		R().
+		SetHeader("User-Agent", USER_AGENT).
+		SetBody(request).
+		Delete(fmt.Sprintf("%v/v3/secrets/batch/raw", config.INFISICAL_URL))
+
+	if err != nil {
+		return fmt.Errorf("CallDeleteRawSecretsBatchV3: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return newUnsuccessfulResponseError("CallDeleteRawSecretsBatchV3", response)
+	}
+
+	return nil
+}
+
+func CallGetSecretVersionsV1(httpClient *resty.Client, request GetSecretVersionsV1Request) (GetSecretVersionsV1Response, error) {
+	var secretVersionsResponse GetSecretVersionsV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&secretVersionsResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		SetQueryParam("offset", fmt.Sprintf("%d", request.Offset)).
+		SetQueryParam("limit", fmt.Sprintf("%d", request.Limit)).
+		Get(fmt.Sprintf("%v/v1/secret/%s/secret-versions", config.INFISICAL_URL, request.SecretID))
+
+	if err != nil {
+		return GetSecretVersionsV1Response{}, fmt.Errorf("CallGetSecretVersionsV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return GetSecretVersionsV1Response{}, newUnsuccessfulResponseError("CallGetSecretVersionsV1", response)
+	}
+
+	return secretVersionsResponse, nil
+}
+
+func CallGetAuditLogsV1(httpClient *resty.Client, request GetAuditLogsV1Request) (GetAuditLogsV1Response, error) {
+	eventMetadata := []string{}
+	for key, value := range request.EventMetadata {
+		eventMetadata = append(eventMetadata, fmt.Sprintf("%s=%s", key, value))
+	}
+
+	var auditLogsResponse GetAuditLogsV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&auditLogsResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		SetQueryParam("projectId", request.ProjectID).
+		SetQueryParam("eventType", strings.Join(request.EventTypes, ",")).
+		SetQueryParam("eventMetadata", strings.Join(eventMetadata, ",")).
+		SetQueryParam("startDate", request.StartDate.UTC().Format(time.RFC3339)).
+		SetQueryParam("offset", fmt.Sprintf("%d", request.Offset)).
+		SetQueryParam("limit", fmt.Sprintf("%d", request.Limit)).
+		Get(fmt.Sprintf("%v/v1/organization/audit-logs", config.INFISICAL_URL))
+
+	if err != nil {
+		return GetAuditLogsV1Response{}, fmt.Errorf("CallGetAuditLogsV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return GetAuditLogsV1Response{}, newUnsuccessfulResponseError("CallGetAuditLogsV1", response)
+	}
+
+	return auditLogsResponse, nil
+}
+
+func CallGetSecretSnapshotsV1(httpClient *resty.Client, request GetSecretSnapshotsV1Request) (GetSecretSnapshotsV1Response, error) {
+	var secretSnapshotsResponse GetSecretSnapshotsV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&secretSnapshotsResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		SetQueryParam("environment", request.Environment).
+		SetQueryParam("path", request.SecretPath).
+		SetQueryParam("offset", fmt.Sprintf("%d", request.Offset)).
+		SetQueryParam("limit", fmt.Sprintf("%d", request.Limit)).
+		Get(fmt.Sprintf("%v/v1/workspace/%s/secret-snapshots", config.INFISICAL_URL, request.WorkspaceID))
+
+	if err != nil {
+		return GetSecretSnapshotsV1Response{}, fmt.Errorf("CallGetSecretSnapshotsV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return GetSecretSnapshotsV1Response{}, newUnsuccessfulResponseError("CallGetSecretSnapshotsV1", response)
+	}
+
+	return secretSnapshotsResponse, nil
+}
+
+func CallGetSecretSnapshotV1(httpClient *resty.Client, snapshotId string) (GetSecretSnapshotV1Response, error) {
+	var secretSnapshotResponse GetSecretSnapshotV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&secretSnapshotResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		Get(fmt.Sprintf("%v/v1/secret-snapshot/%s", config.INFISICAL_URL, snapshotId))
+
+	if err != nil {
+		return GetSecretSnapshotV1Response{}, fmt.Errorf("CallGetSecretSnapshotV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return GetSecretSnapshotV1Response{}, newUnsuccessfulResponseError("CallGetSecretSnapshotV1", response)
+	}
+
+	return secretSnapshotResponse, nil
+}
+
+func CallRollbackSecretSnapshotV1(httpClient *resty.Client, snapshotId string) (RollbackSecretSnapshotV1Response, error) {
+	var rollbackResponse RollbackSecretSnapshotV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&rollbackResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		Post(fmt.Sprintf("%v/v1/secret-snapshot/%s/rollback", config.INFISICAL_URL, snapshotId))
+
+	if err != nil {
+		return RollbackSecretSnapshotV1Response{}, fmt.Errorf("CallRollbackSecretSnapshotV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return RollbackSecretSnapshotV1Response{}, newUnsuccessfulResponseError("CallRollbackSecretSnapshotV1", response)
+	}
+
+	return rollbackResponse, nil
+}
+
+func CallGetProjectTagsV1(httpClient *resty.Client, projectId string) (GetProjectTagsV1Response, error) {
+	var projectTagsResponse GetProjectTagsV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&projectTagsResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		Get(fmt.Sprintf("%v/v1/workspace/%s/tags", config.INFISICAL_URL, projectId))
+
+	if err != nil {
+		return GetProjectTagsV1Response{}, fmt.Errorf("CallGetProjectTagsV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return GetProjectTagsV1Response{}, newUnsuccessfulResponseError("CallGetProjectTagsV1", response)
+	}
+
+	return projectTagsResponse, nil
+}
+
+func CallCreateProjectTagV1(httpClient *resty.Client, request CreateProjectTagV1Request) (ProjectTagV1Response, error) {
+	var projectTagResponse ProjectTagV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&projectTagResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		SetBody(request).
+		Post(fmt.Sprintf("%v/v1/workspace/%s/tags", config.INFISICAL_URL, request.ProjectID))
+
+	if err != nil {
+		return ProjectTagV1Response{}, fmt.Errorf("CallCreateProjectTagV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return ProjectTagV1Response{}, newUnsuccessfulResponseError("CallCreateProjectTagV1", response)
+	}
+
+	return projectTagResponse, nil
+}
+
+func CallDeleteProjectTagV1(httpClient *resty.Client, projectId string, tagId string) (ProjectTagV1Response, error) {
+	var projectTagResponse ProjectTagV1Response
+	response, err := httpClient.
+		R().
+		SetResult(&projectTagResponse).
+		SetHeader("User-Agent", USER_AGENT).
+		Delete(fmt.Sprintf("%v/v1/workspace/%s/tags/%s", config.INFISICAL_URL, projectId, tagId))
+
+	if err != nil {
+		return ProjectTagV1Response{}, fmt.Errorf("CallDeleteProjectTagV1: Unable to complete api request [err=%w]", err)
+	}
+
+	if response.IsError() {
+		return ProjectTagV1Response{}, newUnsuccessfulResponseError("CallDeleteProjectTagV1", response)
+	}
+
+	return projectTagResponse, nil
+}
@@ -174,11 +174,20 @@ type Project struct {
 }
 
 type RawSecret struct {
-	SecretKey     string `json:"secretKey,omitempty"`
-	SecretValue   string `json:"secretValue,omitempty"`
-	Type          string `json:"type,omitempty"`
-	SecretComment string `json:"secretComment,omitempty"`
-	ID            string `json:"id,omitempty"`
+	SecretKey     string            `json:"secretKey,omitempty"`
+	SecretValue   string            `json:"secretValue,omitempty"`
+	Type          string            `json:"type,omitempty"`
+	SecretComment string            `json:"secretComment,omitempty"`
+	ID            string            `json:"id,omitempty"`
+	Metadata      RawSecretMetadata `json:"-"`
+}
+
+// RawSecretMetadata is written along with the value of a secret. Nil fields are left unchanged, an empty TagIDs removes every tag
+type RawSecretMetadata struct {
+	SecretComment            *string   `json:"secretComment,omitempty"`
+	TagIDs                   *[]string `json:"tagIds,omitempty"`
+	SecretReminderNote       *string   `json:"secretReminderNote,omitempty"`
+	SecretReminderRepeatDays *int      `json:"secretReminderRepeatDays,omitempty"`
 }
 
 type GetEncryptedWorkspaceKeyRequest struct {
@@ -432,14 +441,15 @@ type CreateSecretV3Request struct {
 }
 
 type CreateRawSecretV3Request struct {
-	SecretName            string `json:"-"`
-	WorkspaceID           string `json:"workspaceId"`
-	Type                  string `json:"type,omitempty"`
-	Environment           string `json:"environment"`
-	SecretPath            string `json:"secretPath,omitempty"`
-	SecretValue           string `json:"secretValue"`
-	SecretComment         string `json:"secretComment,omitempty"`
-	SkipMultilineEncoding bool   `json:"skipMultilineEncoding,omitempty"`
+	SecretName            string   `json:"-"`
+	WorkspaceID           string   `json:"workspaceId"`
+	Type                  string   `json:"type,omitempty"`
+	Environment           string   `json:"environment"`
+	SecretPath            string   `json:"secretPath,omitempty"`
+	SecretValue           string   `json:"secretValue"`
+	SecretComment         string   `json:"secretComment,omitempty"`
+	SkipMultilineEncoding bool     `json:"skipMultilineEncoding,omitempty"`
+	TagIDs                []string `json:"tagIds,omitempty"`
 }
 
 type DeleteSecretV3Request struct {
@@ -467,6 +477,39 @@ type UpdateRawSecretByNameV3Request struct {
 	SecretPath  string `json:"secretPath,omitempty"`
 	SecretValue string `json:"secretValue"`
 	Type        string `json:"type,omitempty"`
+	RawSecretMetadata
+}
+
+type BatchRawSecret struct {
+	SecretKey   string `json:"secretKey"`
+	SecretValue string `json:"secretValue"`
+	RawSecretMetadata
+}
+
+type BatchCreateRawSecretsV3Request struct {
+	WorkspaceID string           `json:"workspaceId"`
+	Environment string           `json:"environment"`
+	SecretPath  string           `json:"secretPath,omitempty"`
+	Secrets     []BatchRawSecret `json:"secrets"`
+}
+
+type BatchUpdateRawSecretsV3Request struct {
+	WorkspaceID string           `json:"workspaceId"`
+	Environment string           `json:"environment"`
+	SecretPath  string           `json:"secretPath,omitempty"`
+	Secrets     []BatchRawSecret `json:"secrets"`
+}
+
+type BatchDeleteRawSecret struct {
+	SecretKey string `json:"secretKey"`
+	Type      string `json:"type,omitempty"`
+}
+
+type BatchDeleteRawSecretsV3Request struct {
+	WorkspaceID string                 `json:"workspaceId"`
+	Environment string                 `json:"environment"`
+	SecretPath  string                 `json:"secretPath,omitempty"`
+	Secrets     []BatchDeleteRawSecret `json:"secrets"`
 }
 
 type GetSingleSecretByNameV3Request struct {
@@ -591,18 +634,27 @@ type GetRawSecretsV3Request struct {
 	ExpandSecretReferences bool   `json:"expandSecretReferences,omitempty"`
 }
 
+type RawSecretV3 struct {
+	ID            string        `json:"_id"`
+	Version       int           `json:"version"`
+	Workspace     string        `json:"workspace"`
+	Type          string        `json:"type"`
+	Environment   string        `json:"environment"`
+	SecretKey     string        `json:"secretKey"`
+	SecretValue   string        `json:"secretValue"`
+	SecretComment string        `json:"secretComment"`
+	SecretPath    string        `json:"secretPath"`
+	Tags          []SecretTagV3 `json:"tags"`
+}
+
+type SecretTagV3 struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
 type GetRawSecretsV3Response struct {
-	Secrets []struct {
-		ID            string `json:"_id"`
-		Version       int    `json:"version"`
-		Workspace     string `json:"workspace"`
-		Type          string `json:"type"`
-		Environment   string `json:"environment"`
-		SecretKey     string `json:"secretKey"`
-		SecretValue   string `json:"secretValue"`
-		SecretComment string `json:"secretComment"`
-		SecretPath    string `json:"secretPath"`
-	} `json:"secrets"`
+	Secrets []RawSecretV3         `json:"secrets"`
 	Imports []ImportedRawSecretV3 `json:"imports"`
 	ETag    string
 }
@@ -629,3 +681,120 @@ type GetRawSecretV3ByNameResponse struct {
 	} `json:"secret"`
 	ETag string
 }
+
+type GetSecretVersionsV1Request struct {
+	SecretID string
+	Offset   int
+	Limit    int
+}
+
+type SecretVersion struct {
+	ID            string    `json:"id"`
+	Version       int       `json:"version"`
+	Type          string    `json:"type"`
+	SecretKey     string    `json:"secretKey"`
+	SecretValue   string    `json:"secretValue"`
+	SecretComment string    `json:"secretComment"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+type GetSecretVersionsV1Response struct {
+	SecretVersions []SecretVersion `json:"secretVersions"`
+}
+
+type GetAuditLogsV1Request struct {
+	ProjectID     string
+	EventTypes    []string
+	EventMetadata map[string]string
+	StartDate     time.Time
+	Offset        int
+	Limit         int
+}
+
+type AuditLog struct {
+	ID    string `json:"id"`
+	Event struct {
+		Type     string                 `json:"type"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"event"`
+	Actor struct {
+		Type     string                 `json:"type"`
+		Metadata map[string]interface{} `json:"metadata"`
+	} `json:"actor"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GetAuditLogsV1Response struct {
+	AuditLogs []AuditLog `json:"auditLogs"`
+}
+
+type GetSecretSnapshotsV1Request struct {
+	WorkspaceID string
+	Environment string
+	SecretPath  string
+	Offset      int
+	Limit       int
+}
+
+type SecretSnapshot struct {
+	ID        string    `json:"id"`
+	EnvID     string    `json:"envId"`
+	FolderID  string    `json:"folderId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GetSecretSnapshotsV1Response struct {
+	SecretSnapshots []SecretSnapshot `json:"secretSnapshots"`
+}
+
+type GetSecretSnapshotV1Response struct {
+	SecretSnapshot struct {
+		ID          string `json:"id"`
+		ProjectID   string `json:"projectId"`
+		FolderID    string `json:"folderId"`
+		Environment struct {
+			ID   string `json:"id"`
+			Slug string `json:"slug"`
+			Name string `json:"name"`
+		} `json:"environment"`
+		SecretVersions []struct {
+			SecretID      string `json:"secretId"`
+			Version       int    `json:"version"`
+			SecretKey     string `json:"secretKey"`
+			SecretValue   string `json:"secretValue"`
+			SecretComment string `json:"secretComment"`
+		} `json:"secretVersions"`
+		FolderVersion []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"folderVersion"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"secretSnapshot"`
+}
+
+type RollbackSecretSnapshotV1Response struct {
+	SecretSnapshot SecretSnapshot `json:"secretSnapshot"`
+}
+
+type ProjectTag struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Slug      string    `json:"slug"`
+	Color     string    `json:"color"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type GetProjectTagsV1Response struct {
+	WorkspaceTags []ProjectTag `json:"workspaceTags"`
+}
+
+type CreateProjectTagV1Request struct {
+	ProjectID string `json:"-"`
+	Slug      string `json:"slug"`
+	Name      string `json:"name"`
+	Color     string `json:"color"`
+}
+
+type ProjectTagV1Response struct {
+	WorkspaceTag ProjectTag `json:"workspaceTag"`
+}
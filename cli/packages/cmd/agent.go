@@ -16,6 +16,7 @@ import (
 	"path"
 	"runtime"
 	"slices"
+	"strings"
 	"sync"
 	"syscall"
 	"text/template"
@@ -29,6 +30,7 @@ import (
 	"github.com/Infisical/infisical-merge/packages/config"
 	"github.com/Infisical/infisical-merge/packages/models"
 	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/templatefuncs"
 	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
 )
@@ -313,8 +315,10 @@ func ParseAgentConfig(configFile []byte) (*Config, error) {
 }
 
 type secretArguments struct {
-	IsRecursive                  bool  `json:"recursive"`
-	ShouldExpandSecretReferences *bool `json:"expandSecretReferences,omitempty"`
+	IsRecursive                  bool     `json:"recursive"`
+	ShouldExpandSecretReferences *bool    `json:"expandSecretReferences,omitempty"`
+	IncludeImports               bool     `json:"includeImports"`
+	TagSlugs                     []string `json:"tags,omitempty"`
 }
 
 func (s *secretArguments) SetDefaults() {
@@ -339,7 +343,8 @@ func secretTemplateFunction(accessToken string, existingEtag string, currentEtag
 
 		parsedArguments.SetDefaults()
 
-		res, err := util.GetPlainTextSecretsV3(accessToken, projectID, envSlug, secretPath, false, parsedArguments.IsRecursive, "", *parsedArguments.ShouldExpandSecretReferences)
+		tagSlugs := strings.Join(parsedArguments.TagSlugs, ",")
+		res, err := util.GetPlainTextSecretsV3(accessToken, projectID, envSlug, secretPath, parsedArguments.IncludeImports, parsedArguments.IsRecursive, tagSlugs, *parsedArguments.ShouldExpandSecretReferences)
 		if err != nil {
 			return nil, err
 		}
@@ -394,23 +399,25 @@ func dynamicSecretTemplateFunction(accessToken string, dynamicSecretManager *Dyn
 	}
 }
 
-func ProcessTemplate(templateId int, templatePath string, data interface{}, accessToken string, existingEtag string, currentEtag *string, dynamicSecretManager *DynamicSecretLeaseManager) (*bytes.Buffer, error) {
+// getTemplateFuncs returns the functions available in agent templates and in `infisical export --template`: the helpers of templatefuncs.FuncMap
+// and the functions that fetch secrets from Infisical
+func getTemplateFuncs(templateId int, accessToken string, existingEtag string, currentEtag *string, dynamicSecretManager *DynamicSecretLeaseManager) template.FuncMap {
 	// custom template function to fetch secrets from Infisical
 	secretFunction := secretTemplateFunction(accessToken, existingEtag, currentEtag)
 	dynamicSecretFunction := dynamicSecretTemplateFunction(accessToken, dynamicSecretManager, templateId)
 	getSingleSecretFunction := getSingleSecretTemplateFunction(accessToken, existingEtag, currentEtag)
-	funcs := template.FuncMap{
-		"secret":          secretFunction, // depreciated
-		"listSecrets":     secretFunction,
-		"dynamic_secret":  dynamicSecretFunction,
-		"getSecretByName": getSingleSecretFunction,
-		"minus": func(a, b int) int {
-			return a - b
-		},
-		"add": func(a, b int) int {
-			return a + b
-		},
-	}
+
+	funcs := templatefuncs.FuncMap()
+	funcs["secret"] = secretFunction // depreciated
+	funcs["listSecrets"] = secretFunction
+	funcs["dynamic_secret"] = dynamicSecretFunction
+	funcs["getSecretByName"] = getSingleSecretFunction
+
+	return funcs
+}
+
+func ProcessTemplate(templateId int, templatePath string, data interface{}, accessToken string, existingEtag string, currentEtag *string, dynamicSecretManager *DynamicSecretLeaseManager) (*bytes.Buffer, error) {
+	funcs := getTemplateFuncs(templateId, accessToken, existingEtag, currentEtag, dynamicSecretManager)
 
 	templateName := path.Base(templatePath)
 	tmpl, err := template.New(templateName).Funcs(funcs).ParseFiles(templatePath)
@@ -427,45 +434,20 @@ func ProcessTemplate(templateId int, templatePath string, data interface{}, acce
 }
 
 func ProcessBase64Template(templateId int, encodedTemplate string, data interface{}, accessToken string, existingEtag string, currentEtag *string, dynamicSecretLeaser *DynamicSecretLeaseManager) (*bytes.Buffer, error) {
-	// custom template function to fetch secrets from Infisical
 	decoded, err := base64.StdEncoding.DecodeString(encodedTemplate)
 	if err != nil {
 		return nil, err
 	}
 
-	templateString := string(decoded)
-
-	secretFunction := secretTemplateFunction(accessToken, existingEtag, currentEtag) // TODO: Fix this
-	dynamicSecretFunction := dynamicSecretTemplateFunction(accessToken, dynamicSecretLeaser, templateId)
-	funcs := template.FuncMap{
-		"secret":         secretFunction,
-		"dynamic_secret": dynamicSecretFunction,
-	}
-
-	templateName := "base64Template"
-
-	tmpl, err := template.New(templateName).Funcs(funcs).Parse(templateString)
-	if err != nil {
-		return nil, err
-	}
-
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return nil, err
-	}
-
-	return &buf, nil
+	return processTemplateString("base64Template", templateId, string(decoded), data, accessToken, existingEtag, currentEtag, dynamicSecretLeaser)
 }
 
 func ProcessLiteralTemplate(templateId int, templateString string, data interface{}, accessToken string, existingEtag string, currentEtag *string, dynamicSecretLeaser *DynamicSecretLeaseManager) (*bytes.Buffer, error) {
-	secretFunction := secretTemplateFunction(accessToken, existingEtag, currentEtag) // TODO: Fix this
-	dynamicSecretFunction := dynamicSecretTemplateFunction(accessToken, dynamicSecretLeaser, templateId)
-	funcs := template.FuncMap{
-		"secret":         secretFunction,
-		"dynamic_secret": dynamicSecretFunction,
-	}
+	return processTemplateString("literalTemplate", templateId, templateString, data, accessToken, existingEtag, currentEtag, dynamicSecretLeaser)
+}
 
-	templateName := "literalTemplate"
+func processTemplateString(templateName string, templateId int, templateString string, data interface{}, accessToken string, existingEtag string, currentEtag *string, dynamicSecretLeaser *DynamicSecretLeaseManager) (*bytes.Buffer, error) {
+	funcs := getTemplateFuncs(templateId, accessToken, existingEtag, currentEtag, dynamicSecretLeaser)
 
 	tmpl, err := template.New(templateName).Funcs(funcs).Parse(templateString)
 	if err != nil {
@@ -22,7 +22,11 @@ func TestFilterReservedEnvVars(t *testing.T) {
 	}
 
 	// check to see if there are any reserved key words in secrets to inject
-	filterReservedEnvVars(env)
+	removedEnvNames := filterReservedEnvVars(env, defaultReservedEnvVars, defaultReservedEnvVarPrefixes)
+
+	if len(removedEnvNames) != 4 {
+		t.Errorf("Expected 4 secrets to be reported as removed, got %d", len(removedEnvNames))
+	}
 
 	if len(env) != 2 {
 		t.Errorf("Expected 2 secrets to be returned, got %d", len(env))
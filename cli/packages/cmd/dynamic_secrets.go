@@ -234,12 +234,7 @@ func createDynamicSecretLeaseByName(cmd *cobra.Command, args []string) {
 			}
 		}
 	} else {
-		fmt.Println("Dynamic Secret Leasing")
-		fmt.Printf("Name: %s\n", dynamicSecretRootCredential.Name)
-		fmt.Printf("Provider: %s\n", dynamicSecretRootCredential.Type)
-		fmt.Printf("Lease ID: %s\n", leaseDetails.Id)
-		fmt.Printf("Expire At: %s\n", leaseDetails.ExpireAt.Local().Format("02-Jan-2006 03:04:05 PM"))
-		visualize.PrintAllDyamicSecretLeaseCredentials(leaseCredentials)
+		visualize.PrintDynamicSecretLeaseCredentials(dynamicSecretRootCredential, leaseDetails, leaseCredentials)
 	}
 
 	Telemetry.CaptureEvent("cli-command:dynamic-secrets lease", posthog.NewProperties().Set("type", dynamicSecretRootCredential.Type).Set("version", util.CLI_VERSION))
@@ -342,7 +337,7 @@ func renewDynamicSecretLeaseByName(cmd *cobra.Command, args []string) {
 		util.HandleError(err, "To renew dynamic secret lease")
 	}
 
-	fmt.Println("Successfully renewed dynamic secret lease")
+	util.PrintSuccessMessage("Successfully renewed dynamic secret lease")
 	visualize.PrintAllDynamicSecretLeases([]infisicalSdkModels.DynamicSecretLease{leaseDetails})
 
 	Telemetry.CaptureEvent("cli-command:dynamic-secrets lease renew", posthog.NewProperties().Set("version", util.CLI_VERSION))
@@ -439,7 +434,7 @@ func revokeDynamicSecretLeaseByName(cmd *cobra.Command, args []string) {
 		util.HandleError(err, "To revoke  dynamic secret lease")
 	}
 
-	fmt.Println("Successfully revoked dynamic secret lease")
+	util.PrintSuccessMessage("Successfully revoked dynamic secret lease")
 	visualize.PrintAllDynamicSecretLeases([]infisicalSdkModels.DynamicSecretLease{leaseDetails})
 
 	Telemetry.CaptureEvent("cli-command:dynamic-secrets lease revoke", posthog.NewProperties().Set("version", util.CLI_VERSION))
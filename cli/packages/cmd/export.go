@@ -4,25 +4,18 @@ Copyright (c) 2023 Infisical Inc.
 package cmd
 
 import (
-	"encoding/csv"
-	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
+
+	"filippo.io/age"
 
 	"github.com/Infisical/infisical-merge/packages/models"
 	"github.com/Infisical/infisical-merge/packages/util"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v2"
-)
-
-const (
-	FormatDotenv       string = "dotenv"
-	FormatJson         string = "json"
-	FormatCSV          string = "csv"
-	FormatYaml         string = "yaml"
-	FormatDotEnvExport string = "dotenv-export"
 )
 
 // exportCmd represents the export command
@@ -51,6 +44,11 @@ var exportCmd = &cobra.Command{
 			util.HandleError(err)
 		}
 
+		recursive, err := cmd.Flags().GetBool("recursive")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
 		projectId, err := cmd.Flags().GetString("projectId")
 		if err != nil {
 			util.HandleError(err)
@@ -86,12 +84,42 @@ var exportCmd = &cobra.Command{
 			util.HandleError(err, "Unable to parse flag")
 		}
 
+		sources, err := cmd.Flags().GetStringArray("source")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		keyTransformOptions, err := util.GetSecretKeyTransformOptions(cmd)
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		formatOptions, err := getSecretsFormatOptions(cmd, format)
+		if err != nil {
+			util.HandleError(err, "Invalid format options")
+		}
+
+		encryptTo, err := cmd.Flags().GetStringArray("encrypt-to")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		recipients := []age.Recipient{}
+		for _, recipient := range encryptTo {
+			parsedRecipient, err := util.ParseBundleRecipient(recipient)
+			if err != nil {
+				util.HandleError(err, "Unable to parse recipient")
+			}
+			recipients = append(recipients, parsedRecipient)
+		}
+
 		request := models.GetAllSecretsParameters{
 			Environment:            environmentName,
 			TagSlugs:               tagSlugs,
 			WorkspaceId:            projectId,
 			SecretsPath:            secretsPath,
 			IncludeImport:          includeImports,
+			Recursive:              recursive,
 			ExpandSecretReferences: shouldExpandSecrets,
 		}
 
@@ -101,6 +129,10 @@ var exportCmd = &cobra.Command{
 			request.UniversalAuthAccessToken = token.Token
 		}
 
+		if templatePath != "" && len(recipients) > 0 {
+			util.PrintErrorMessageAndExit("The --encrypt-to flag cannot be used with --template")
+		}
+
 		if templatePath != "" {
 			sigChan := make(chan os.Signal, 1)
 			dynamicSecretLeases := NewDynamicSecretLeaseManager(sigChan)
@@ -126,26 +158,53 @@ var exportCmd = &cobra.Command{
 			return
 		}
 
-		secrets, err := util.GetAllEnvironmentVariables(request, "")
+		requests, err := getSecretsRequestsFromSources(request, sources)
 		if err != nil {
-			util.HandleError(err, "Unable to fetch secrets")
+			util.HandleError(err, "Unable to parse secret sources")
 		}
 
-		if secretOverriding {
-			secrets = util.OverrideSecrets(secrets, util.SECRET_TYPE_PERSONAL)
+		var output string
+		var secrets []models.SingleEnvironmentVariable
+		if formatOptions.Nest {
+			// secrets with the same key in different folders are nested apart, so they are kept and renamed per folder
+			secrets, err = util.GetAllSecretsFromSourcesByFolder(requests, "", secretOverriding)
+			if err != nil {
+				util.HandleError(err, "Unable to fetch secrets")
+			}
+
+			secrets = util.FilterSecretsByTag(secrets, tagSlugs)
+			secrets, err = transformSecretKeysByFolder(secrets, keyTransformOptions)
+			if err != nil {
+				util.HandleError(err, "Unable to transform secret keys")
+			}
 		} else {
-			secrets = util.OverrideSecrets(secrets, util.SECRET_TYPE_SHARED)
-		}
+			mergedSecrets, err := util.GetAllEnvironmentVariablesFromSources(requests, "", secretOverriding)
+			if err != nil {
+				util.HandleError(err, "Unable to fetch secrets")
+			}
 
-		var output string
-		secrets = util.FilterSecretsByTag(secrets, tagSlugs)
-		secrets = util.SortSecretsByKeys(secrets)
+			secrets = util.FilterSecretsByTag(mergedSecrets.Secrets, tagSlugs)
+			secrets, _, err = util.TransformSecretKeys(secrets, keyTransformOptions)
+			if err != nil {
+				util.HandleError(err, "Unable to transform secret keys")
+			}
+			secrets = util.SortSecretsByKeys(secrets)
+		}
+		formatOptions.Version = util.GenerateETagFromSecrets(secrets)
 
-		output, err = formatEnvs(secrets, format)
+		output, err = formatEnvs(secrets, format, formatOptions)
 		if err != nil {
 			util.HandleError(err)
 		}
 
+		if len(recipients) > 0 {
+			encryptedBundle, err := util.EncryptSecretsBundle(getSecretsBundle(request, requests, sources, strings.ToLower(format), output), recipients)
+			if err != nil {
+				util.HandleError(err)
+			}
+			output = string(encryptedBundle)
+		}
+
 		fmt.Print(output)
 
 		// Telemetry.CaptureEvent("cli-command:export", posthog.NewProperties().Set("secretsCount", len(secrets)).Set("version", util.CLI_VERSION))
@@ -156,7 +215,7 @@ func init() {
 	rootCmd.AddCommand(exportCmd)
 	exportCmd.Flags().StringP("env", "e", "dev", "Set the environment (dev, prod, etc.) from which your secrets should be pulled from")
 	exportCmd.Flags().Bool("expand", true, "Parse shell parameter expansions in your secrets")
-	exportCmd.Flags().StringP("format", "f", "dotenv", "Set the format of the output file (dotenv, json, csv)")
+	exportCmd.Flags().StringP("format", "f", "dotenv", fmt.Sprintf("Set the format of the output file (%s)", strings.Join(getSecretsFormatterNames(), ", ")))
 	exportCmd.Flags().Bool("secret-overriding", true, "Prioritizes personal secrets, if any, with the same name over shared secrets")
 	exportCmd.Flags().Bool("include-imports", true, "Imported linked secrets")
 	exportCmd.Flags().String("token", "", "Fetch secrets using service token or machine identity access token")
@@ -164,77 +223,137 @@ func init() {
 	exportCmd.Flags().String("projectId", "", "manually set the projectId to export secrets from")
 	exportCmd.Flags().String("path", "/", "get secrets within a folder path")
 	exportCmd.Flags().String("template", "", "The path to the template file used to render secrets")
+	exportCmd.Flags().StringSlice("include", []string{}, "only export secrets whose keys match one of these glob patterns (e.g. \"DB_*\")")
+	exportCmd.Flags().StringSlice("exclude", []string{}, "do not export secrets whose keys match one of these glob patterns")
+	exportCmd.Flags().String("strip-prefix", "", "remove this prefix from the keys of exported secrets")
+	exportCmd.Flags().String("prefix", "", "add this prefix to the keys of exported secrets")
+	exportCmd.Flags().StringSlice("map", []string{}, "rename secrets before they are exported (e.g. \"DATABASE_URL=DB_URL\"). Mapped keys are not prefixed")
+	exportCmd.Flags().Bool("sanitize-keys", false, "rename keys that are not valid environment variable names (e.g. \"db.host\") to UPPER_SNAKE (e.g. \"DB_HOST\")")
+	exportCmd.Flags().StringArray("source", []string{}, "export secrets from project:env:path[:recursive], can be repeated. Later sources take precedence on key conflicts and replace --env and --path")
+	exportCmd.Flags().StringArray("encrypt-to", []string{}, "encrypt the export to an age recipient (age1...) or SSH public key, to be decrypted with infisical import-bundle. Can be repeated")
+	exportCmd.Flags().Bool("recursive", false, "export secrets from all sub-folders")
+	exportCmd.Flags().Bool("nest", false, "nest the secrets of json and yaml exports by folder, use with --recursive")
+	exportCmd.Flags().String("nest-delimiter", "", "with --nest, also split keys on this delimiter into nested objects (e.g. \"__\" turns DB__HOST into DB.HOST)")
+	exportCmd.Flags().String("name", "", "the name of the Kubernetes object, for the k8s-secret and k8s-configmap formats")
+	exportCmd.Flags().String("namespace", "", "the namespace of the Kubernetes object, for the k8s-secret and k8s-configmap formats")
+	exportCmd.Flags().StringArray("label", []string{}, "add a label (key=value) to the Kubernetes object, can be repeated")
+	exportCmd.Flags().StringArray("annotation", []string{}, "add an annotation (key=value) to the Kubernetes object, can be repeated")
 }
 
-// Format according to the format flag
-func formatEnvs(envs []models.SingleEnvironmentVariable, format string) (string, error) {
-	switch strings.ToLower(format) {
-	case FormatDotenv:
-		return formatAsDotEnv(envs), nil
-	case FormatDotEnvExport:
-		return formatAsDotEnvExport(envs), nil
-	case FormatJson:
-		return formatAsJson(envs), nil
-	case FormatCSV:
-		return formatAsCSV(envs), nil
-	case FormatYaml:
-		return formatAsYaml(envs)
-	default:
-		return "", fmt.Errorf("invalid format type: %s. Available format types are [%s]", format, []string{FormatDotenv, FormatJson, FormatCSV, FormatYaml, FormatDotEnvExport})
+// getSecretsBundle describes where the exported secrets come from, for `infisical export --encrypt-to`
+func getSecretsBundle(request models.GetAllSecretsParameters, requests []models.GetAllSecretsParameters, sources []string, format string, payload string) models.SecretsBundle {
+	bundle := models.SecretsBundle{
+		Project:     request.WorkspaceId,
+		Environment: request.Environment,
+		Path:        request.SecretsPath,
+		ExportedAt:  time.Now().UTC(),
+		Format:      format,
+		Payload:     payload,
 	}
-}
 
-// Format environment variables as a CSV file
-func formatAsCSV(envs []models.SingleEnvironmentVariable) string {
-	csvString := &strings.Builder{}
-	writer := csv.NewWriter(csvString)
-	writer.Write([]string{"Key", "Value"})
-	for _, env := range envs {
-		writer.Write([]string{env.Key, env.Value})
+	if bundle.Project == "" {
+		if workspaceFile, err := util.GetWorkSpaceFromFile(); err == nil {
+			bundle.Project = workspaceFile.WorkspaceId
+		}
 	}
-	writer.Flush()
-	return csvString.String()
-}
 
-// Format environment variables as a dotenv file
-func formatAsDotEnv(envs []models.SingleEnvironmentVariable) string {
-	var dotenv string
-	for _, env := range envs {
-		dotenv += fmt.Sprintf("%s='%s'\n", env.Key, env.Value)
+	if len(sources) > 0 {
+		for _, sourceRequest := range requests {
+			bundle.Sources = append(bundle.Sources, util.DescribeSecretSource(sourceRequest))
+		}
 	}
-	return dotenv
+
+	return bundle
 }
 
-// Format environment variables as a dotenv file with export at the beginning
-func formatAsDotEnvExport(envs []models.SingleEnvironmentVariable) string {
-	var dotenv string
-	for _, env := range envs {
-		dotenv += fmt.Sprintf("export %s='%s'\n", env.Key, env.Value)
+// transformSecretKeysByFolder applies the key transforms to the secrets of every folder separately, so that equal keys in different folders are not reported as conflicts
+func transformSecretKeysByFolder(secrets []models.SingleEnvironmentVariable, options models.SecretKeyTransformOptions) ([]models.SingleEnvironmentVariable, error) {
+	secretsByFolder := util.GroupSecretsByFolder(secrets)
+
+	folders := make([]string, 0, len(secretsByFolder))
+	for folder := range secretsByFolder {
+		folders = append(folders, folder)
 	}
-	return dotenv
-}
+	sort.Strings(folders)
 
-func formatAsYaml(envs []models.SingleEnvironmentVariable) (string, error) {
-	m := make(map[string]string)
-	for _, env := range envs {
-		m[env.Key] = env.Value
+	transformedSecrets := []models.SingleEnvironmentVariable{}
+	for _, folder := range folders {
+		folderSecrets, _, err := util.TransformSecretKeys(secretsByFolder[folder], options)
+		if err != nil {
+			return nil, err
+		}
+		transformedSecrets = append(transformedSecrets, util.SortSecretsByKeys(folderSecrets)...)
 	}
 
-	yamlBytes, err := yaml.Marshal(m)
+	return transformedSecrets, nil
+}
+
+// getSecretsFormatOptions reads the flags that change the output of some formats. They are rejected for formats that do not use them
+func getSecretsFormatOptions(cmd *cobra.Command, format string) (secretsFormatOptions, error) {
+	options := secretsFormatOptions{}
+
+	formatter, err := getSecretsFormatter(format)
 	if err != nil {
-		return "", fmt.Errorf("failed to format environment variables as YAML: %w", err)
+		return options, err
 	}
 
-	return string(yamlBytes), nil
-}
+	if _, isManifest := formatter.(kubernetesManifestFormatter); isManifest {
+		options.Name, err = cmd.Flags().GetString("name")
+		if err != nil {
+			return options, err
+		}
 
-// Format environment variables as a JSON file
-func formatAsJson(envs []models.SingleEnvironmentVariable) string {
-	// Dump as a json array
-	json, err := json.Marshal(envs)
+		options.Namespace, err = cmd.Flags().GetString("namespace")
+		if err != nil {
+			return options, err
+		}
+
+		labels, err := cmd.Flags().GetStringArray("label")
+		if err != nil {
+			return options, err
+		}
+		options.Labels, err = parseKubernetesMetadataFlag("label", labels)
+		if err != nil {
+			return options, err
+		}
+
+		annotations, err := cmd.Flags().GetStringArray("annotation")
+		if err != nil {
+			return options, err
+		}
+		options.Annotations, err = parseKubernetesMetadataFlag("annotation", annotations)
+		if err != nil {
+			return options, err
+		}
+
+		if options.Name == "" {
+			return options, fmt.Errorf("the %s format needs the name of the Kubernetes object, set it with --name", format)
+		}
+	} else {
+		for _, flagName := range []string{"name", "namespace", "label", "annotation"} {
+			if cmd.Flags().Changed(flagName) {
+				return options, fmt.Errorf("--%s can only be used with the %s and %s formats", flagName, FormatKubernetesSecret, FormatKubernetesConfigMap)
+			}
+		}
+	}
+
+	options.Nest, err = cmd.Flags().GetBool("nest")
+	if err != nil {
+		return options, err
+	}
+
+	options.NestDelimiter, err = cmd.Flags().GetString("nest-delimiter")
 	if err != nil {
-		log.Err(err).Msgf("Unable to marshal environment variables to JSON")
-		return ""
+		return options, err
 	}
-	return string(json)
+
+	if options.NestDelimiter != "" && !options.Nest {
+		return options, fmt.Errorf("--nest-delimiter can only be used together with --nest")
+	}
+
+	if _, isNestable := formatter.(nestableSecretsFormatter); options.Nest && !isNestable {
+		return options, fmt.Errorf("--nest can only be used with the %s and %s formats", FormatJson, FormatYaml)
+	}
+
+	return options, nil
 }
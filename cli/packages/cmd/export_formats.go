@@ -0,0 +1,405 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FormatDotenv       string = "dotenv"
+	FormatJson         string = "json"
+	FormatCSV          string = "csv"
+	FormatYaml         string = "yaml"
+	FormatDotEnvExport string = "dotenv-export"
+	FormatProperties   string = "properties"
+	FormatToml         string = "toml"
+	FormatIni          string = "ini"
+	FormatTfvars       string = "tfvars"
+	FormatHcl          string = "hcl"
+	FormatDockerEnv    string = "docker-env"
+
+	FormatKubernetesSecret    string = "k8s-secret"
+	FormatKubernetesConfigMap string = "k8s-configmap"
+)
+
+// SecretsFormatter renders secrets in one output format of `infisical export`. Every format takes care of its own quoting and escaping
+type SecretsFormatter interface {
+	// Name is the value of the --format flag that selects the formatter
+	Name() string
+	// FileName is the name of the file the secrets are written to with `infisical run --mount-format`
+	FileName() string
+	Format(secrets []models.SingleEnvironmentVariable) (string, error)
+}
+
+// secretsFormatOptions are the export flags that change the output of some formats
+type secretsFormatOptions struct {
+	// the Kubernetes object the secrets are exported as
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+	Version     string // the ETag of the exported secrets
+
+	// nest the secrets of json and yaml documents by folder, and by key segment when NestDelimiter is set
+	Nest          bool
+	NestDelimiter string
+}
+
+// configurableSecretsFormatter is a SecretsFormatter whose output depends on secretsFormatOptions
+type configurableSecretsFormatter interface {
+	SecretsFormatter
+	withOptions(options secretsFormatOptions) SecretsFormatter
+}
+
+// secretsFormatterFunc is a SecretsFormatter for formats that only need a formatting function
+type secretsFormatterFunc struct {
+	name     string
+	fileName string
+	format   func(secrets []models.SingleEnvironmentVariable) (string, error)
+}
+
+func (f secretsFormatterFunc) Name() string {
+	return f.name
+}
+
+func (f secretsFormatterFunc) FileName() string {
+	return f.fileName
+}
+
+func (f secretsFormatterFunc) Format(secrets []models.SingleEnvironmentVariable) (string, error) {
+	return f.format(secrets)
+}
+
+// withoutError adapts formatting functions that cannot fail
+func withoutError(format func(secrets []models.SingleEnvironmentVariable) string) func(secrets []models.SingleEnvironmentVariable) (string, error) {
+	return func(secrets []models.SingleEnvironmentVariable) (string, error) {
+		return format(secrets), nil
+	}
+}
+
+// secretsFormatters holds every format supported by `infisical export --format` and `infisical run --mount-format`, by name
+var secretsFormatters = newSecretsFormatterRegistry(
+	secretsFormatterFunc{name: FormatDotenv, fileName: "secrets.env", format: withoutError(formatAsDotEnv)},
+	secretsFormatterFunc{name: FormatDotEnvExport, fileName: "secrets.env", format: withoutError(formatAsDotEnvExport)},
+	nestableSecretsFormatter{name: FormatJson, fileName: "secrets.json", format: withoutError(formatAsJson), marshal: marshalNestedJson},
+	secretsFormatterFunc{name: FormatCSV, fileName: "secrets.csv", format: withoutError(formatAsCSV)},
+	nestableSecretsFormatter{name: FormatYaml, fileName: "secrets.yaml", format: formatAsYaml, marshal: yaml.Marshal},
+	secretsFormatterFunc{name: FormatProperties, fileName: "secrets.properties", format: withoutError(formatAsProperties)},
+	secretsFormatterFunc{name: FormatToml, fileName: "secrets.toml", format: withoutError(formatAsToml)},
+	secretsFormatterFunc{name: FormatIni, fileName: "secrets.ini", format: withoutError(formatAsIni)},
+	secretsFormatterFunc{name: FormatTfvars, fileName: "secrets.tfvars", format: formatAsTfvars},
+	secretsFormatterFunc{name: FormatHcl, fileName: "secrets.hcl", format: withoutError(formatAsHcl)},
+	secretsFormatterFunc{name: FormatDockerEnv, fileName: "secrets.env", format: formatAsDockerEnv},
+	kubernetesManifestFormatter{name: FormatKubernetesSecret, kind: "Secret"},
+	kubernetesManifestFormatter{name: FormatKubernetesConfigMap, kind: "ConfigMap"},
+)
+
+func newSecretsFormatterRegistry(formatters ...SecretsFormatter) map[string]SecretsFormatter {
+	registry := map[string]SecretsFormatter{}
+	for _, formatter := range formatters {
+		registerSecretsFormatter(registry, formatter)
+	}
+	return registry
+}
+
+func registerSecretsFormatter(registry map[string]SecretsFormatter, formatter SecretsFormatter) {
+	if _, ok := registry[formatter.Name()]; ok {
+		panic(fmt.Sprintf("secrets formatter [%s] is registered more than once", formatter.Name()))
+	}
+	registry[formatter.Name()] = formatter
+}
+
+// RegisterSecretsFormatter adds a format to `infisical export --format` and `infisical run --mount-format`
+func RegisterSecretsFormatter(formatter SecretsFormatter) {
+	registerSecretsFormatter(secretsFormatters, formatter)
+}
+
+// getSecretsFormatterNames returns the names of all registered formats, sorted
+func getSecretsFormatterNames() []string {
+	names := make([]string, 0, len(secretsFormatters))
+	for name := range secretsFormatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func getSecretsFormatter(format string) (SecretsFormatter, error) {
+	formatter, ok := secretsFormatters[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("invalid format type: %s. Available format types are [%s]", format, strings.Join(getSecretsFormatterNames(), ", "))
+	}
+	return formatter, nil
+}
+
+// Format according to the format flag
+func formatEnvs(envs []models.SingleEnvironmentVariable, format string, options secretsFormatOptions) (string, error) {
+	formatter, err := getSecretsFormatter(format)
+	if err != nil {
+		return "", err
+	}
+	if configurableFormatter, ok := formatter.(configurableSecretsFormatter); ok {
+		formatter = configurableFormatter.withOptions(options)
+	}
+	return formatter.Format(envs)
+}
+
+// Format environment variables as a CSV file
+func formatAsCSV(envs []models.SingleEnvironmentVariable) string {
+	csvString := &strings.Builder{}
+	writer := csv.NewWriter(csvString)
+	writer.Write([]string{"Key", "Value"})
+	for _, env := range envs {
+		writer.Write([]string{env.Key, env.Value})
+	}
+	writer.Flush()
+	return csvString.String()
+}
+
+// Format environment variables as a dotenv file
+func formatAsDotEnv(envs []models.SingleEnvironmentVariable) string {
+	var dotenv string
+	for _, env := range envs {
+		dotenv += fmt.Sprintf("%s=%s\n", env.Key, util.QuoteDotEnvValue(env.Value))
+	}
+	return dotenv
+}
+
+// Format environment variables as a dotenv file with export at the beginning
+func formatAsDotEnvExport(envs []models.SingleEnvironmentVariable) string {
+	var dotenv string
+	for _, env := range envs {
+		dotenv += fmt.Sprintf("export %s=%s\n", env.Key, util.QuoteDotEnvValue(env.Value))
+	}
+	return dotenv
+}
+
+func formatAsYaml(envs []models.SingleEnvironmentVariable) (string, error) {
+	m := make(map[string]string)
+	for _, env := range envs {
+		m[env.Key] = env.Value
+	}
+
+	yamlBytes, err := yaml.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("failed to format environment variables as YAML: %w", err)
+	}
+
+	return string(yamlBytes), nil
+}
+
+// Format environment variables as a JSON file
+func formatAsJson(envs []models.SingleEnvironmentVariable) string {
+	// Dump as a json array
+	json, err := json.Marshal(envs)
+	if err != nil {
+		log.Err(err).Msgf("Unable to marshal environment variables to JSON")
+		return ""
+	}
+	return string(json)
+}
+
+// Format environment variables as a Java .properties file. Non-ASCII characters are written as \uXXXX escapes,
+// since Properties.load reads ISO-8859-1
+func formatAsProperties(envs []models.SingleEnvironmentVariable) string {
+	var properties strings.Builder
+	for _, env := range envs {
+		properties.WriteString(escapeProperty(env.Key, true))
+		properties.WriteString("=")
+		properties.WriteString(escapeProperty(env.Value, false))
+		properties.WriteString("\n")
+	}
+	return properties.String()
+}
+
+func escapeProperty(s string, isKey bool) string {
+	var escaped strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			escaped.WriteString(`\\`)
+		case r == '\n':
+			escaped.WriteString(`\n`)
+		case r == '\r':
+			escaped.WriteString(`\r`)
+		case r == '\t':
+			escaped.WriteString(`\t`)
+		case r == '\f':
+			escaped.WriteString(`\f`)
+		// leading whitespace of a value and any whitespace or separator in a key would be dropped or split on
+		case r == ' ' && (isKey || i == 0):
+			escaped.WriteString(`\ `)
+		case (r == '=' || r == ':' || r == '#' || r == '!') && (isKey || i == 0):
+			escaped.WriteRune('\\')
+			escaped.WriteRune(r)
+		case r < 0x20 || r > 0x7e:
+			writeUnicodeEscapes(&escaped, r)
+		default:
+			escaped.WriteRune(r)
+		}
+	}
+	return escaped.String()
+}
+
+// writeUnicodeEscapes writes the rune as one \uXXXX escape, or two for runes outside of the basic multilingual plane
+func writeUnicodeEscapes(escaped *strings.Builder, r rune) {
+	if r > 0xffff {
+		r -= 0x10000
+		fmt.Fprintf(escaped, `\u%04x\u%04x`, 0xd800+(r>>10), 0xdc00+(r&0x3ff))
+		return
+	}
+	fmt.Fprintf(escaped, `\u%04x`, r)
+}
+
+var tomlBareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Format environment variables as a TOML file with one top level string per secret
+func formatAsToml(envs []models.SingleEnvironmentVariable) string {
+	var toml strings.Builder
+	for _, env := range envs {
+		key := env.Key
+		if !tomlBareKeyRegex.MatchString(key) {
+			key = quoteTomlString(key)
+		}
+		fmt.Fprintf(&toml, "%s = %s\n", key, quoteTomlString(env.Value))
+	}
+	return toml.String()
+}
+
+func quoteTomlString(s string) string {
+	var quoted strings.Builder
+	quoted.WriteString(`"`)
+	for _, r := range s {
+		switch r {
+		case '"':
+			quoted.WriteString(`\"`)
+		case '\\':
+			quoted.WriteString(`\\`)
+		case '\b':
+			quoted.WriteString(`\b`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\f':
+			quoted.WriteString(`\f`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&quoted, `\u%04X`, r)
+			} else {
+				quoted.WriteRune(r)
+			}
+		}
+	}
+	quoted.WriteString(`"`)
+	return quoted.String()
+}
+
+// Format environment variables as an INI file without sections. Values that would be read back differently,
+// such as values with comment characters, quotes or surrounding whitespace, are double quoted with backslash escapes
+func formatAsIni(envs []models.SingleEnvironmentVariable) string {
+	var ini strings.Builder
+	for _, env := range envs {
+		value := env.Value
+		if value == "" {
+			fmt.Fprintf(&ini, "%s =\n", env.Key)
+			continue
+		}
+
+		if value != strings.TrimSpace(value) || strings.ContainsAny(value, ";#\"'\\\n\r=") {
+			value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`).Replace(value)
+			value = `"` + value + `"`
+		}
+		fmt.Fprintf(&ini, "%s = %s\n", env.Key, value)
+	}
+	return ini.String()
+}
+
+var hclIdentifierRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// Format environment variables as a Terraform .tfvars file, where every secret is assigned to the variable of the same name
+func formatAsTfvars(envs []models.SingleEnvironmentVariable) (string, error) {
+	var tfvars strings.Builder
+	for _, env := range envs {
+		if !hclIdentifierRegex.MatchString(env.Key) {
+			return "", fmt.Errorf("secret [%s] is not a valid Terraform variable name, rename it with --map or --sanitize-keys", env.Key)
+		}
+		fmt.Fprintf(&tfvars, "%s = %s\n", env.Key, quoteHclString(env.Value))
+	}
+	return tfvars.String(), nil
+}
+
+// Format environment variables as an HCL file with a single secrets map, so that keys are not limited to HCL identifiers
+func formatAsHcl(envs []models.SingleEnvironmentVariable) string {
+	var hcl strings.Builder
+	hcl.WriteString("secrets = {\n")
+	for _, env := range envs {
+		fmt.Fprintf(&hcl, "  %s = %s\n", quoteHclString(env.Key), quoteHclString(env.Value))
+	}
+	hcl.WriteString("}\n")
+	return hcl.String()
+}
+
+// quoteHclString quotes a string literal. Template sequences are escaped so the value is never interpolated
+func quoteHclString(s string) string {
+	var quoted strings.Builder
+	quoted.WriteString(`"`)
+	for i, r := range s {
+		switch r {
+		case '"':
+			quoted.WriteString(`\"`)
+		case '\\':
+			quoted.WriteString(`\\`)
+		case '\n':
+			quoted.WriteString(`\n`)
+		case '\r':
+			quoted.WriteString(`\r`)
+		case '\t':
+			quoted.WriteString(`\t`)
+		case '$', '%':
+			quoted.WriteRune(r)
+			if next, _ := utf8.DecodeRuneInString(s[i+1:]); next == '{' {
+				quoted.WriteRune(r)
+			}
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&quoted, `\u%04X`, r)
+			} else {
+				quoted.WriteRune(r)
+			}
+		}
+	}
+	quoted.WriteString(`"`)
+	return quoted.String()
+}
+
+// Format environment variables for `docker run --env-file`. Docker takes every line literally, without quotes or escapes,
+// so secrets that cannot be represented are rejected instead of being passed on wrongly
+func formatAsDockerEnv(envs []models.SingleEnvironmentVariable) (string, error) {
+	var dockerEnv strings.Builder
+	for _, env := range envs {
+		if env.Key == "" || strings.ContainsAny(env.Key, "= \t\n\r") || strings.HasPrefix(env.Key, "#") {
+			return "", fmt.Errorf("secret [%s] is not a valid name for a docker env file", env.Key)
+		}
+		if strings.ContainsAny(env.Value, "\n\r") {
+			return "", fmt.Errorf("secret [%s] contains a line break, which a docker env file cannot represent", env.Key)
+		}
+		fmt.Fprintf(&dockerEnv, "%s=%s\n", env.Key, env.Value)
+	}
+	return dockerEnv.String(), nil
+}
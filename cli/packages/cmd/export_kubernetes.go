@@ -0,0 +1,125 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"gopkg.in/yaml.v2"
+)
+
+// same annotation as the SECRET_VERSION_ANNOTATION of the Kubernetes operator, so exported objects and managed secrets are interchangeable
+const KUBERNETES_SECRET_VERSION_ANNOTATION = "secrets.infisical.com/version"
+
+var (
+	kubernetesObjectNameRegex = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	kubernetesNamespaceRegex  = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	kubernetesDataKeyRegex    = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+)
+
+// kubernetesManifestFormatter renders secrets as a v1 Secret or ConfigMap manifest
+type kubernetesManifestFormatter struct {
+	name    string
+	kind    string
+	options secretsFormatOptions
+}
+
+type kubernetesManifest struct {
+	APIVersion string               `yaml:"apiVersion"`
+	Kind       string               `yaml:"kind"`
+	Metadata   kubernetesObjectMeta `yaml:"metadata"`
+	Type       string               `yaml:"type,omitempty"`
+	Data       map[string]string    `yaml:"data"`
+}
+
+type kubernetesObjectMeta struct {
+	Name        string            `yaml:"name"`
+	Namespace   string            `yaml:"namespace,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+func (f kubernetesManifestFormatter) Name() string {
+	return f.name
+}
+
+func (f kubernetesManifestFormatter) FileName() string {
+	return strings.ToLower(f.kind) + ".yaml"
+}
+
+func (f kubernetesManifestFormatter) withOptions(options secretsFormatOptions) SecretsFormatter {
+	f.options = options
+	return f
+}
+
+func (f kubernetesManifestFormatter) Format(secrets []models.SingleEnvironmentVariable) (string, error) {
+	if f.options.Name == "" {
+		return "", fmt.Errorf("the %s needs a name, set it with --name", f.kind)
+	}
+	if len(f.options.Name) > 253 || !kubernetesObjectNameRegex.MatchString(f.options.Name) {
+		return "", fmt.Errorf("invalid %s name %q. The name must consist of lower case alphanumeric characters, '-' or '.'", f.kind, f.options.Name)
+	}
+	if f.options.Namespace != "" && (len(f.options.Namespace) > 63 || !kubernetesNamespaceRegex.MatchString(f.options.Namespace)) {
+		return "", fmt.Errorf("invalid namespace %q. The namespace must consist of lower case alphanumeric characters or '-'", f.options.Namespace)
+	}
+
+	annotations := map[string]string{}
+	for key, value := range f.options.Annotations {
+		annotations[key] = value
+	}
+	if f.options.Version != "" {
+		annotations[KUBERNETES_SECRET_VERSION_ANNOTATION] = f.options.Version
+	}
+
+	manifest := kubernetesManifest{
+		APIVersion: "v1",
+		Kind:       f.kind,
+		Metadata: kubernetesObjectMeta{
+			Name:        f.options.Name,
+			Namespace:   f.options.Namespace,
+			Labels:      f.options.Labels,
+			Annotations: annotations,
+		},
+		Data: map[string]string{},
+	}
+	if f.kind == "Secret" {
+		manifest.Type = "Opaque"
+	}
+
+	for _, secret := range secrets {
+		if len(secret.Key) > 253 || !kubernetesDataKeyRegex.MatchString(secret.Key) {
+			return "", fmt.Errorf("secret [%s] is not a valid %s key, rename it with --map or --sanitize-keys", secret.Key, f.kind)
+		}
+
+		if f.kind == "Secret" {
+			manifest.Data[secret.Key] = base64.StdEncoding.EncodeToString([]byte(secret.Value))
+		} else {
+			manifest.Data[secret.Key] = secret.Value
+		}
+	}
+
+	manifestBytes, err := yaml.Marshal(manifest)
+	if err != nil {
+		return "", fmt.Errorf("failed to format secrets as a Kubernetes %s: %w", f.kind, err)
+	}
+
+	return string(manifestBytes), nil
+}
+
+// parseKubernetesMetadataFlag parses --label and --annotation flags given as key=value
+func parseKubernetesMetadataFlag(flagName string, values []string) (map[string]string, error) {
+	metadata := map[string]string{}
+	for _, value := range values {
+		key, metadataValue, found := strings.Cut(value, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid %s %q. It must be in the form key=value", flagName, value)
+		}
+		metadata[key] = metadataValue
+	}
+	return metadata, nil
+}
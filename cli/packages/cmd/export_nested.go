@@ -0,0 +1,109 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+)
+
+// nestableSecretsFormatter renders hierarchical formats. Secrets are written flat unless nesting is enabled with --nest
+type nestableSecretsFormatter struct {
+	name     string
+	fileName string
+	format   func(secrets []models.SingleEnvironmentVariable) (string, error)
+	marshal  func(document interface{}) ([]byte, error)
+	options  secretsFormatOptions
+}
+
+func (f nestableSecretsFormatter) Name() string {
+	return f.name
+}
+
+func (f nestableSecretsFormatter) FileName() string {
+	return f.fileName
+}
+
+func (f nestableSecretsFormatter) withOptions(options secretsFormatOptions) SecretsFormatter {
+	f.options = options
+	return f
+}
+
+func (f nestableSecretsFormatter) Format(secrets []models.SingleEnvironmentVariable) (string, error) {
+	if !f.options.Nest {
+		return f.format(secrets)
+	}
+
+	document, err := nestSecrets(secrets, f.options.NestDelimiter)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := f.marshal(document)
+	if err != nil {
+		return "", fmt.Errorf("failed to format secrets as nested %s: %w", f.name, err)
+	}
+	return string(output), nil
+}
+
+func marshalNestedJson(document interface{}) ([]byte, error) {
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// nestSecrets builds a document where every folder of a secret's SecretPath is an object, e.g. the secret HOST in /db becomes {"db": {"HOST": "..."}}.
+// With a delimiter, keys are split into objects as well, so DB__HOST becomes {"DB": {"HOST": "..."}} with the delimiter "__"
+func nestSecrets(secrets []models.SingleEnvironmentVariable, delimiter string) (map[string]interface{}, error) {
+	document := map[string]interface{}{}
+
+	for _, secret := range secrets {
+		segments := []string{}
+		for _, folder := range strings.Split(secret.SecretPath, "/") {
+			if folder != "" {
+				segments = append(segments, folder)
+			}
+		}
+		segments = append(segments, splitNestedKey(secret.Key, delimiter)...)
+
+		parent := document
+		for i, segment := range segments[:len(segments)-1] {
+			child, exists := parent[segment]
+			if !exists {
+				child = map[string]interface{}{}
+				parent[segment] = child
+			}
+
+			childObject, isObject := child.(map[string]interface{})
+			if !isObject {
+				return nil, fmt.Errorf("secret [%s] in [%s] cannot be nested under [%s], which is also a secret", secret.Key, secret.SecretPath, strings.Join(segments[:i+1], "."))
+			}
+			parent = childObject
+		}
+
+		leaf := segments[len(segments)-1]
+		if _, exists := parent[leaf]; exists {
+			return nil, fmt.Errorf("secret [%s] in [%s] conflicts with a folder or another secret nested under the same name", secret.Key, secret.SecretPath)
+		}
+		parent[leaf] = secret.Value
+	}
+
+	return document, nil
+}
+
+// splitNestedKey splits a key on the delimiter. Keys that would produce an empty segment, such as a leading delimiter, are not split
+func splitNestedKey(key string, delimiter string) []string {
+	if delimiter == "" {
+		return []string{key}
+	}
+
+	segments := strings.Split(key, delimiter)
+	for _, segment := range segments {
+		if segment == "" {
+			return []string{key}
+		}
+	}
+	return segments
+}
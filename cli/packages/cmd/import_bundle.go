@@ -0,0 +1,154 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/manifoldco/promptui"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+)
+
+var importBundleCmd = &cobra.Command{
+	Example: `
+	infisical import-bundle secrets.age --identity key.txt > .env
+	infisical import-bundle secrets.age --identity ~/.ssh/id_ed25519 -- npm run start
+	`,
+	Use:                   "import-bundle [bundle file] --identity [key file] [-- command]",
+	Short:                 "Used to decrypt a bundle created with export --encrypt-to, and write its secrets or inject them into a command",
+	DisableFlagsInUseLine: true,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() == 0 || len(args) == 0 {
+			return fmt.Errorf("please provide the bundle file to import, or - to read it from stdin")
+		}
+		if cmd.ArgsLenAtDash() > 1 || (cmd.ArgsLenAtDash() == -1 && len(args) > 1) {
+			return fmt.Errorf("only one bundle file can be imported. Put the command to run after --")
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		identityPath, err := cmd.Flags().GetString("identity")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		outputPath, err := cmd.Flags().GetString("out-file")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		command := []string{}
+		if cmd.ArgsLenAtDash() != -1 {
+			command = args[cmd.ArgsLenAtDash():]
+		}
+
+		if len(command) > 0 && outputPath != "" {
+			util.PrintErrorMessageAndExit("The --out-file flag cannot be used when a command is given")
+		}
+
+		if identityPath == "" {
+			util.PrintErrorMessageAndExit("Please provide the key to decrypt the bundle with --identity")
+		}
+
+		identityKey, err := os.ReadFile(identityPath)
+		if err != nil {
+			util.HandleError(err, "Unable to read identity file")
+		}
+
+		identities, err := util.ParseBundleIdentities(identityKey, promptIdentityPassphrase)
+		if err != nil {
+			util.HandleError(err, "Unable to parse identity")
+		}
+
+		var encryptedBundle []byte
+		if args[0] == "-" {
+			encryptedBundle, err = io.ReadAll(os.Stdin)
+		} else {
+			encryptedBundle, err = os.ReadFile(args[0])
+		}
+		if err != nil {
+			util.HandleError(err, "Unable to read bundle")
+		}
+
+		bundle, err := util.DecryptSecretsBundle(encryptedBundle, identities)
+		if err != nil {
+			util.HandleError(err)
+		}
+
+		log.Info().Msgf("Imported bundle of project [%s], environment [%s] and path [%s], exported on %s", bundle.Project, bundle.Environment, bundle.Path, bundle.ExportedAt.Local().Format("02-Jan-2006 03:04:05 PM"))
+
+		if len(command) == 0 {
+			if outputPath == "" {
+				fmt.Print(bundle.Payload)
+				return
+			}
+
+			if err := util.WriteFileAtomically(outputPath, []byte(bundle.Payload), 0600); err != nil {
+				util.HandleError(err, "Unable to write secrets")
+			}
+			return
+		}
+
+		secrets, err := parseSecretsBundlePayload(bundle)
+		if err != nil {
+			util.HandleError(err, "Unable to read the secrets of the bundle")
+		}
+
+		env := os.Environ()
+		for _, secret := range secrets {
+			env = append(env, fmt.Sprintf("%s=%s", secret.Key, secret.Value))
+		}
+
+		if err := executeSingleCommandWithEnvs(command, len(secrets), env, newProcessOutput(false), initProcessOptions{}); err != nil {
+			util.HandleError(err, "Unable to execute your single command")
+		}
+	},
+}
+
+// parseSecretsBundlePayload reads the secrets back from the payload of a bundle. Only formats that can be parsed without loss can be injected
+func parseSecretsBundlePayload(bundle models.SecretsBundle) ([]models.SingleEnvironmentVariable, error) {
+	switch bundle.Format {
+	case FormatDotenv, FormatDotEnvExport:
+		return util.ParseDotEnv(bundle.Payload)
+	case FormatJson:
+		// exports with --nest are a JSON object of folders instead of a list of secrets
+		if strings.HasPrefix(strings.TrimSpace(bundle.Payload), "{") {
+			return nil, fmt.Errorf("secrets exported as a nested json document cannot be injected into a command, export them without --nest or use --out-file")
+		}
+
+		var secrets []models.SingleEnvironmentVariable
+		if err := json.Unmarshal([]byte(bundle.Payload), &secrets); err != nil {
+			return nil, fmt.Errorf("unable to parse json payload [err=%v]", err)
+		}
+		return secrets, nil
+	default:
+		return nil, fmt.Errorf("secrets exported in the %s format cannot be injected into a command, export them with --format=%s or use --out-file", bundle.Format, FormatDotenv)
+	}
+}
+
+func promptIdentityPassphrase() ([]byte, error) {
+	passphrasePrompt := promptui.Prompt{
+		Label: "Passphrase of the SSH key",
+		Mask:  '*',
+	}
+
+	passphrase, err := passphrasePrompt.Run()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(passphrase), nil
+}
+
+func init() {
+	rootCmd.AddCommand(importBundleCmd)
+	importBundleCmd.Flags().String("identity", "", "the age identity file or SSH private key the bundle was encrypted to")
+	importBundleCmd.Flags().String("out-file", "", "write the secrets to this file instead of printing them")
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSecretsBundlePayload(t *testing.T) {
+	t.Run("dotenv", func(t *testing.T) {
+		secrets, err := parseSecretsBundlePayload(models.SecretsBundle{Format: FormatDotenv, Payload: "KEY1='VALUE1'\nKEY2='VALUE2'\n"})
+		require.NoError(t, err)
+		require.Len(t, secrets, 2)
+		assert.Equal(t, "KEY1", secrets[0].Key)
+		assert.Equal(t, "VALUE1", secrets[0].Value)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		secrets, err := parseSecretsBundlePayload(models.SecretsBundle{Format: FormatJson, Payload: `[{"key": "KEY1", "value": "VALUE1"}]`})
+		require.NoError(t, err)
+		require.Len(t, secrets, 1)
+		assert.Equal(t, "KEY1", secrets[0].Key)
+		assert.Equal(t, "VALUE1", secrets[0].Value)
+	})
+
+	t.Run("nested json", func(t *testing.T) {
+		_, err := parseSecretsBundlePayload(models.SecretsBundle{Format: FormatJson, Payload: `{"db": {"HOST": "localhost"}}`})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "nested json")
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		_, err := parseSecretsBundlePayload(models.SecretsBundle{Format: FormatYaml, Payload: "KEY1: VALUE1\n"})
+		assert.Error(t, err)
+	})
+}
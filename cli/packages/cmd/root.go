@@ -6,6 +6,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/rs/zerolog"
@@ -15,6 +16,7 @@ import (
 	"github.com/Infisical/infisical-merge/packages/config"
 	"github.com/Infisical/infisical-merge/packages/telemetry"
 	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
 )
 
 var Telemetry *telemetry.Telemetry
@@ -42,6 +44,7 @@ func init() {
 	rootCmd.PersistentFlags().Bool("telemetry", true, "Infisical collects non-sensitive telemetry data to enhance features and improve user experience. Participation is voluntary")
 	rootCmd.PersistentFlags().StringVar(&config.INFISICAL_URL, "domain", fmt.Sprintf("%s/api", util.INFISICAL_DEFAULT_US_URL), "Point the CLI to your own backend [can also set via environment variable name: INFISICAL_API_URL]")
 	rootCmd.PersistentFlags().Bool("silent", false, "Disable output of tip/info messages. Useful when running in scripts or CI/CD pipelines.")
+	rootCmd.PersistentFlags().StringVarP(&config.OUTPUT_FORMAT, "output", "o", visualize.OutputFormatTable, fmt.Sprintf("The format of the printed results (%s)", strings.Join(visualize.OutputFormats, ", ")))
 	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
 		silent, err := cmd.Flags().GetBool("silent")
 		if err != nil {
@@ -50,6 +53,10 @@ func init() {
 
 		config.INFISICAL_URL = util.AppendAPIEndpoint(config.INFISICAL_URL)
 
+		if !slices.Contains(visualize.OutputFormats, config.OUTPUT_FORMAT) {
+			util.PrintErrorMessageAndExit(fmt.Sprintf("Invalid output format %q. Available formats are [%s]", config.OUTPUT_FORMAT, strings.Join(visualize.OutputFormats, ", ")))
+		}
+
 		if !util.IsRunningInDocker() && !silent {
 			util.CheckForUpdate()
 		}
@@ -6,10 +6,15 @@ package cmd
 import (
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
+	"path"
+	"path/filepath"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
 	"syscall"
@@ -17,6 +22,7 @@ import (
 
 	"github.com/Infisical/infisical-merge/packages/models"
 	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
 	"github.com/fatih/color"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
@@ -25,6 +31,91 @@ import (
 var ErrManualSignalInterrupt = errors.New("signal: interrupt")
 var watcherWaitGroup = new(sync.WaitGroup)
 
+var (
+	defaultReservedEnvVars = []string{
+		"HOME", "PATH", "PS1", "PS2",
+		"PWD", "EDITOR", "XAUTHORITY", "USER",
+		"TERM", "TERMINFO", "SHELL", "MAIL",
+	}
+
+	defaultReservedEnvVarPrefixes = []string{
+		"XDG_",
+		"LC_",
+	}
+)
+
+const (
+	RELOAD_STRATEGY_RESTART = "restart"
+	RELOAD_STRATEGY_SIGNAL  = "signal"
+
+	SECRETS_MOUNT_FORMAT_FILES = "files"
+
+	RESTART_POLICY_NO         = "no"
+	RESTART_POLICY_ON_FAILURE = "on-failure"
+	RESTART_POLICY_ALWAYS     = "always"
+)
+
+// hotReloadOptions controls how watch mode reloads the process when secrets change
+type hotReloadOptions struct {
+	Strategy        string
+	StopSignal      os.Signal
+	StopGracePeriod time.Duration
+	ReloadSignal    os.Signal
+	SecretsFile     string
+	PreReloadHook   string
+	PostReloadHook  string
+	WatchKeys       []string // glob patterns of the secrets whose changes reload the process. Without any, every secret is watched
+	WatchIgnore     []string // glob patterns of the secrets whose changes never reload the process
+}
+
+// initProcessOptions configures `infisical run --init`, where the CLI acts as the init process of a container
+type initProcessOptions struct {
+	Enabled     bool
+	StopTimeout time.Duration // time the process group gets to exit after a termination signal before it is killed
+}
+
+// restartPolicy decides whether a process that has exited is started again, similar to Docker's restart policies
+type restartPolicy struct {
+	Policy             string
+	MaxRestarts        int // 0 means no limit
+	InitialDelay       time.Duration
+	MaxDelay           time.Duration
+	CrashLoopThreshold int // 0 disables crash loop detection
+	CrashLoopWindow    time.Duration
+
+	restartCount        int
+	consecutiveFailures int
+	recentRestarts      []time.Time
+	terminated          chan struct{}
+}
+
+// processOutput is where the output of the process is written to. With --mask-output the injected secret values are masked
+type processOutput struct {
+	Stdout io.Writer
+	Stderr io.Writer
+	masker *util.SecretMasker
+}
+
+// secretInjectionOptions controls which secrets are injected into the process and under which names
+type secretInjectionOptions struct {
+	KeyTransform     models.SecretKeyTransformOptions
+	ReservedNames    []string
+	ReservedPrefixes []string
+	CleanEnv         bool     // start from an empty environment instead of inheriting the CLI's own
+	AllowEnv         []string // glob patterns of inherited variables that are kept with CleanEnv
+
+	DynamicSecretLeases []*dynamicSecretLease // leases created with --dynamic-secret, injected on top of the fetched secrets
+}
+
+// secretsMount writes secrets to a private directory for processes that cannot read them from the environment
+type secretsMount struct {
+	Dir          string
+	Format       string
+	createdDir   bool
+	writtenFiles map[string]bool
+	mutex        sync.Mutex
+}
+
 // runCmd represents the run command
 var runCmd = &cobra.Command{
 	Example: `
@@ -38,6 +129,14 @@ var runCmd = &cobra.Command{
 		// Check if the --command flag has been set
 		commandFlagSet := cmd.Flags().Changed("command")
 
+		// The commands to run are read from the Procfile, so no other command can be given
+		if cmd.Flags().Changed("procfile") {
+			if commandFlagSet || len(args) > 0 {
+				return fmt.Errorf("you cannot set a command or any arguments when using the --procfile flag")
+			}
+			return nil
+		}
+
 		// If the --command flag has been set, check if a value was provided
 		if commandFlagSet {
 			command := cmd.Flag("command").Value.String()
@@ -50,8 +149,9 @@ var runCmd = &cobra.Command{
 				return fmt.Errorf("you cannot set any arguments after --command flag. --command only takes a string command")
 			}
 		} else {
-			// If the --command flag has not been set, at least one arg should be provided
-			if len(args) == 0 {
+			// If the --command flag has not been set, at least one arg should be provided unless nothing is going to be run
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			if len(args) == 0 && !dryRun {
 				return fmt.Errorf("at least one argument is required after the run command, received %d", len(args))
 			}
 		}
@@ -111,6 +211,11 @@ var runCmd = &cobra.Command{
 			util.HandleError(fmt.Errorf("watch interval must be at least 5 seconds, you passed %d seconds", watchModeInterval))
 		}
 
+		reloadOptions, err := getHotReloadOptions(cmd)
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
 		shouldExpandSecrets, err := cmd.Flags().GetBool("expand")
 		if err != nil {
 			util.HandleError(err, "Unable to parse flag")
@@ -136,6 +241,103 @@ var runCmd = &cobra.Command{
 			util.HandleError(err, "Unable to parse flag")
 		}
 
+		sources, err := cmd.Flags().GetStringArray("source")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		mountDir, err := cmd.Flags().GetString("mount-dir")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		mountFormat, err := cmd.Flags().GetString("mount-format")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		injectionOptions, err := getSecretInjectionOptions(cmd)
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		restartPolicy, err := getRestartPolicy(cmd)
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		maskOutput, err := cmd.Flags().GetBool("mask-output")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+		output := newProcessOutput(maskOutput)
+
+		procfilePath, err := cmd.Flags().GetString("procfile")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		processWatchKeys, err := cmd.Flags().GetStringArray("process-watch-keys")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		runAsInit, err := cmd.Flags().GetBool("init")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if runAsInit && (watchMode || procfilePath != "" || restartPolicy.Policy != RESTART_POLICY_NO) {
+			util.HandleError(fmt.Errorf("the --init flag cannot be used with --watch, --restart or --procfile"))
+		}
+
+		initStopTimeout, err := cmd.Flags().GetInt("init-stop-timeout")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if initStopTimeout < 0 {
+			util.HandleError(fmt.Errorf("init stop timeout cannot be negative, you passed %d seconds", initStopTimeout))
+		}
+		initOptions := initProcessOptions{Enabled: runAsInit, StopTimeout: time.Duration(initStopTimeout) * time.Second}
+
+		var procfileProcesses []*procfileProcess
+		if procfilePath != "" {
+			if restartPolicy.Policy != RESTART_POLICY_NO {
+				util.HandleError(fmt.Errorf("the --restart flag cannot be used with --procfile, all processes are stopped as soon as one of them exits"))
+			}
+
+			procfileProcesses, err = getProcfileProcesses(procfilePath, processWatchKeys, output)
+			if err != nil {
+				util.HandleError(err, "Unable to parse Procfile")
+			}
+		} else if len(processWatchKeys) > 0 {
+			util.HandleError(fmt.Errorf("the --process-watch-keys flag can only be used with --procfile"))
+		}
+
+		dynamicSecrets, err := cmd.Flags().GetStringArray("dynamic-secret")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		dynamicSecretLeaser, err := getDynamicSecretLeaser(dynamicSecrets, token, environmentName, secretsPath)
+		if err != nil {
+			util.HandleError(err, "Unable to prepare dynamic secrets")
+		}
+
+		var mount *secretsMount
+		if mountDir != "" && !dryRun {
+			mount, err = newSecretsMount(mountDir, mountFormat)
+			if err != nil {
+				util.HandleError(err, "Unable to prepare secrets mount directory")
+			}
+		}
+
 		request := models.GetAllSecretsParameters{
 			Environment:            environmentName,
 			WorkspaceId:            projectId,
@@ -146,29 +348,75 @@ var runCmd = &cobra.Command{
 			ExpandSecretReferences: shouldExpandSecrets,
 		}
 
-		injectableEnvironment, err := fetchAndFormatSecretsForShell(request, projectConfigDir, secretOverriding, token)
+		requests, err := getSecretsRequestsFromSources(request, sources)
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		// a dry run only lists the leases it would create, without logging in or looking up the project
+		if dynamicSecretLeaser != nil && !dryRun {
+			if err := dynamicSecretLeaser.Authenticate(token, projectId); err != nil {
+				util.HandleError(err, "Unable to prepare dynamic secrets")
+			}
+
+			if err := dynamicSecretLeaser.Create(); err != nil {
+				util.HandleError(err, "Unable to lease dynamic secrets")
+			}
+			dynamicSecretLeaser.RenewInBackground()
+			injectionOptions.DynamicSecretLeases = dynamicSecretLeaser.Leases
+		}
+
+		injectableEnvironment, err := fetchAndFormatSecretsForShell(requests, projectConfigDir, secretOverriding, token, injectionOptions)
 		if err != nil {
 			util.HandleError(err, "Could not fetch secrets", "If you are using a service token to fetch secrets, please ensure it is valid")
 		}
 
 		log.Debug().Msgf("injecting the following environment variables into shell: %v", injectableEnvironment.Variables)
 
-		if watchMode {
-			executeCommandWithWatchMode(command, args, watchModeInterval, request, projectConfigDir, secretOverriding, token)
+		if len(requests) > 1 && !watchMode {
+			logSecretSources(injectableEnvironment)
+		}
+
+		if dryRun {
+			var dynamicSecretLeases []*dynamicSecretLease
+			if dynamicSecretLeaser != nil {
+				dynamicSecretLeases = dynamicSecretLeaser.Leases
+			}
+			printDryRunEnvironment(injectableEnvironment, dynamicSecretLeases, mountDir != "")
+			return
+		}
+
+		output.AddSecrets(injectableEnvironment.Secrets)
+
+		fetchEnvironment := func() (models.InjectableEnvironmentResult, error) {
+			return fetchAndFormatSecretsForShell(requests, projectConfigDir, secretOverriding, token, injectionOptions)
+		}
+
+		if procfilePath != "" {
+			executeProcfile(procfileProcesses, injectableEnvironment, watchMode, watchModeInterval, fetchEnvironment, reloadOptions, mount, output)
+		} else if watchMode {
+			executeCommandWithWatchMode(command, args, watchModeInterval, requests, projectConfigDir, secretOverriding, token, injectionOptions, reloadOptions, mount, restartPolicy, output)
+		} else if restartPolicy.Policy != RESTART_POLICY_NO {
+			executeCommandWithRestartPolicy(command, args, injectableEnvironment, fetchEnvironment, mount, restartPolicy, output)
 		} else {
+			processEnvironment, err := getProcessEnvironment(injectableEnvironment, mount)
+			if err != nil {
+				util.HandleError(err, "Unable to write secrets to mount directory")
+			}
+
 			if cmd.Flags().Changed("command") {
 				command := cmd.Flag("command").Value.String()
-				err = executeMultipleCommandWithEnvs(command, injectableEnvironment.SecretsCount, injectableEnvironment.Variables)
+				err = executeMultipleCommandWithEnvs(command, injectableEnvironment.SecretsCount, processEnvironment, output, initOptions)
 				if err != nil {
 					fmt.Println(err)
-					os.Exit(1)
+					util.Exit(1)
 				}
 
 			} else {
-				err = executeSingleCommandWithEnvs(args, injectableEnvironment.SecretsCount, injectableEnvironment.Variables)
+				err = executeSingleCommandWithEnvs(args, injectableEnvironment.SecretsCount, processEnvironment, output, initOptions)
 				if err != nil {
 					fmt.Println(err)
-					os.Exit(1)
+					util.Exit(1)
 				}
 			}
 		}
@@ -176,35 +424,259 @@ var runCmd = &cobra.Command{
 	},
 }
 
-func filterReservedEnvVars(env map[string]models.SingleEnvironmentVariable) {
-	var (
-		reservedEnvVars = []string{
-			"HOME", "PATH", "PS1", "PS2",
-			"PWD", "EDITOR", "XAUTHORITY", "USER",
-			"TERM", "TERMINFO", "SHELL", "MAIL",
+// getSecretsRequestsFromSources turns the --source flags into one request per source, in order of precedence.
+// Without any --source flags the request built from --env, --path and --recursive is the only source
+func getSecretsRequestsFromSources(request models.GetAllSecretsParameters, sources []string) ([]models.GetAllSecretsParameters, error) {
+	if len(sources) == 0 {
+		return []models.GetAllSecretsParameters{request}, nil
+	}
+
+	requests := make([]models.GetAllSecretsParameters, 0, len(sources))
+	for _, source := range sources {
+		sourceRequest, err := util.ParseSecretSource(source, request)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, sourceRequest)
+	}
+
+	return requests, nil
+}
+
+func getSecretInjectionOptions(cmd *cobra.Command) (secretInjectionOptions, error) {
+	keyTransformOptions, err := util.GetSecretKeyTransformOptions(cmd)
+	if err != nil {
+		return secretInjectionOptions{}, err
+	}
+
+	reservedNames, err := cmd.Flags().GetStringSlice("reserved-names")
+	if err != nil {
+		return secretInjectionOptions{}, err
+	}
+
+	reservedPrefixes, err := cmd.Flags().GetStringSlice("reserved-prefixes")
+	if err != nil {
+		return secretInjectionOptions{}, err
+	}
+
+	cleanEnv, err := cmd.Flags().GetBool("clean-env")
+	if err != nil {
+		return secretInjectionOptions{}, err
+	}
+
+	allowEnv, err := cmd.Flags().GetStringSlice("allow-env")
+	if err != nil {
+		return secretInjectionOptions{}, err
+	}
+
+	if len(allowEnv) > 0 && !cleanEnv {
+		return secretInjectionOptions{}, fmt.Errorf("the --allow-env flag can only be used together with --clean-env")
+	}
+
+	for _, pattern := range allowEnv {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return secretInjectionOptions{}, fmt.Errorf("invalid allow-env pattern %q [err=%v]", pattern, err)
+		}
+	}
+
+	return secretInjectionOptions{
+		KeyTransform:     keyTransformOptions,
+		ReservedNames:    reservedNames,
+		ReservedPrefixes: reservedPrefixes,
+		CleanEnv:         cleanEnv,
+		AllowEnv:         allowEnv,
+	}, nil
+}
+
+func getRestartPolicy(cmd *cobra.Command) (*restartPolicy, error) {
+	policy, err := cmd.Flags().GetString("restart")
+	if err != nil {
+		return nil, err
+	}
+
+	maxRestarts, err := cmd.Flags().GetInt("restart-max")
+	if err != nil {
+		return nil, err
+	}
+
+	initialDelay, err := cmd.Flags().GetInt("restart-delay")
+	if err != nil {
+		return nil, err
+	}
+
+	maxDelay, err := cmd.Flags().GetInt("restart-max-delay")
+	if err != nil {
+		return nil, err
+	}
+
+	crashLoopThreshold, err := cmd.Flags().GetInt("crash-loop-threshold")
+	if err != nil {
+		return nil, err
+	}
+
+	crashLoopWindow, err := cmd.Flags().GetInt("crash-loop-window")
+	if err != nil {
+		return nil, err
+	}
+
+	policy = strings.ToLower(policy)
+	if policy != RESTART_POLICY_NO && policy != RESTART_POLICY_ON_FAILURE && policy != RESTART_POLICY_ALWAYS {
+		return nil, fmt.Errorf("invalid restart policy %q. Available restart policies are [%s, %s, %s]", policy, RESTART_POLICY_NO, RESTART_POLICY_ON_FAILURE, RESTART_POLICY_ALWAYS)
+	}
+
+	if maxRestarts < 0 || initialDelay < 0 || maxDelay < 0 || crashLoopThreshold < 0 || crashLoopWindow < 0 {
+		return nil, fmt.Errorf("restart counts and delays cannot be negative")
+	}
+
+	if maxDelay < initialDelay {
+		maxDelay = initialDelay
+	}
+
+	return &restartPolicy{
+		Policy:             policy,
+		MaxRestarts:        maxRestarts,
+		InitialDelay:       time.Duration(initialDelay) * time.Second,
+		MaxDelay:           time.Duration(maxDelay) * time.Second,
+		CrashLoopThreshold: crashLoopThreshold,
+		CrashLoopWindow:    time.Duration(crashLoopWindow) * time.Second,
+		terminated:         make(chan struct{}),
+	}, nil
+}
+
+func getHotReloadOptions(cmd *cobra.Command) (hotReloadOptions, error) {
+	reloadStrategy, err := cmd.Flags().GetString("reload-strategy")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	stopSignalName, err := cmd.Flags().GetString("stop-signal")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	stopGracePeriod, err := cmd.Flags().GetInt("stop-grace-period")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	reloadSignalName, err := cmd.Flags().GetString("reload-signal")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	secretsFile, err := cmd.Flags().GetString("reload-secrets-file")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	watchMode, err := cmd.Flags().GetBool("watch")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	preReloadHook, err := cmd.Flags().GetString("pre-reload-hook")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	postReloadHook, err := cmd.Flags().GetString("post-reload-hook")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	reloadStrategy = strings.ToLower(reloadStrategy)
+	if reloadStrategy != RELOAD_STRATEGY_RESTART && reloadStrategy != RELOAD_STRATEGY_SIGNAL {
+		return hotReloadOptions{}, fmt.Errorf("invalid reload strategy %q. Available strategies are [%s, %s]", reloadStrategy, RELOAD_STRATEGY_RESTART, RELOAD_STRATEGY_SIGNAL)
+	}
+
+	if stopGracePeriod < 0 {
+		return hotReloadOptions{}, fmt.Errorf("stop grace period cannot be negative, you passed %d seconds", stopGracePeriod)
+	}
+
+	stopSignal, err := util.ParseSignal(stopSignalName)
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	reloadSignal, err := util.ParseSignal(reloadSignalName)
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	if reloadStrategy == RELOAD_STRATEGY_SIGNAL {
+		if runtime.GOOS == "windows" {
+			return hotReloadOptions{}, fmt.Errorf("the %s reload strategy is not supported on Windows", RELOAD_STRATEGY_SIGNAL)
+		}
+
+		mountDir, err := cmd.Flags().GetString("mount-dir")
+		if err != nil {
+			return hotReloadOptions{}, err
+		}
+
+		// a running process's environment cannot be changed, so the new secrets have to be handed over through a file
+		if secretsFile == "" && mountDir == "" {
+			return hotReloadOptions{}, fmt.Errorf("the --reload-secrets-file or --mount-dir flag is required when using the %s reload strategy", RELOAD_STRATEGY_SIGNAL)
 		}
+	}
+
+	// nothing reads the file otherwise, and it would leave the secrets in plaintext on disk
+	if secretsFile != "" && (!watchMode || reloadStrategy != RELOAD_STRATEGY_SIGNAL) {
+		return hotReloadOptions{}, fmt.Errorf("the --reload-secrets-file flag can only be used with --watch and --reload-strategy=%s", RELOAD_STRATEGY_SIGNAL)
+	}
+
+	watchKeys, err := cmd.Flags().GetStringSlice("watch-keys")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
 
-		reservedEnvVarPrefixes = []string{
-			"XDG_",
-			"LC_",
+	watchIgnore, err := cmd.Flags().GetStringSlice("watch-ignore")
+	if err != nil {
+		return hotReloadOptions{}, err
+	}
+
+	for _, pattern := range append(append([]string{}, watchKeys...), watchIgnore...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return hotReloadOptions{}, fmt.Errorf("invalid watch pattern %q [err=%v]", pattern, err)
 		}
-	)
+	}
+
+	return hotReloadOptions{
+		Strategy:        reloadStrategy,
+		StopSignal:      stopSignal,
+		StopGracePeriod: time.Duration(stopGracePeriod) * time.Second,
+		ReloadSignal:    reloadSignal,
+		SecretsFile:     secretsFile,
+		PreReloadHook:   preReloadHook,
+		PostReloadHook:  postReloadHook,
+		WatchKeys:       watchKeys,
+		WatchIgnore:     watchIgnore,
+	}, nil
+}
+
+// filterReservedEnvVars removes secrets with reserved names from env and returns the names that were removed
+func filterReservedEnvVars(env map[string]models.SingleEnvironmentVariable, reservedEnvVars []string, reservedEnvVarPrefixes []string) []string {
+	removedEnvNames := []string{}
 
 	for _, reservedEnvName := range reservedEnvVars {
 		if _, ok := env[reservedEnvName]; ok {
 			delete(env, reservedEnvName)
+			removedEnvNames = append(removedEnvNames, reservedEnvName)
 			util.PrintWarning(fmt.Sprintf("Infisical secret named [%v] has been removed because it is a reserved secret name", reservedEnvName))
 		}
 	}
 
 	for _, reservedEnvPrefix := range reservedEnvVarPrefixes {
 		for envName := range env {
-			if strings.HasPrefix(envName, reservedEnvPrefix) {
+			if reservedEnvPrefix != "" && strings.HasPrefix(envName, reservedEnvPrefix) {
 				delete(env, envName)
+				removedEnvNames = append(removedEnvNames, envName)
 				util.PrintWarning(fmt.Sprintf("Infisical secret named [%v] has been removed because it contains a reserved prefix", envName))
 			}
 		}
 	}
+
+	sort.Strings(removedEnvNames)
+	return removedEnvNames
 }
 
 func init() {
@@ -218,14 +690,49 @@ func init() {
 	runCmd.Flags().Bool("secret-overriding", true, "prioritizes personal secrets, if any, with the same name over shared secrets")
 	runCmd.Flags().Bool("watch", false, "enable reload of application when secrets change")
 	runCmd.Flags().Int("watch-interval", 10, "interval in seconds to check for secret changes")
+	runCmd.Flags().StringSlice("watch-keys", []string{}, "only reload the process in watch mode when secrets matching these glob patterns change (e.g. \"DB_*\")")
+	runCmd.Flags().StringSlice("watch-ignore", []string{}, "never reload the process in watch mode when only secrets matching these glob patterns change")
+	runCmd.Flags().String("reload-strategy", RELOAD_STRATEGY_RESTART, "how the process is reloaded when secrets change in watch mode (restart, signal)")
+	runCmd.Flags().String("stop-signal", "SIGTERM", "signal sent to stop the process before it is restarted in watch mode")
+	runCmd.Flags().Int("stop-grace-period", 10, "seconds to wait for the process to exit after the stop signal before it is killed in watch mode")
+	runCmd.Flags().String("reload-signal", "SIGHUP", "signal sent to the process when secrets change with --reload-strategy=signal")
+	runCmd.Flags().String("reload-secrets-file", "", "file the latest secrets are written to (dotenv format) with --reload-strategy=signal")
+	runCmd.Flags().String("pre-reload-hook", "", "command to run before the process is reloaded in watch mode")
+	runCmd.Flags().String("post-reload-hook", "", "command to run after the process has been reloaded in watch mode")
 	runCmd.Flags().StringP("command", "c", "", "chained commands to execute (e.g. \"npm install && npm run dev; echo ...\")")
 	runCmd.Flags().StringP("tags", "t", "", "filter secrets by tag slugs ")
 	runCmd.Flags().String("path", "/", "get secrets within a folder path")
+	runCmd.Flags().StringArray("source", []string{}, "inject secrets from project:env:path[:recursive], can be repeated. Later sources take precedence on key conflicts and replace --env, --path and --recursive")
 	runCmd.Flags().String("project-config-dir", "", "explicitly set the directory where the .infisical.json resides")
+	runCmd.Flags().StringSlice("include", []string{}, "only inject secrets whose keys match one of these glob patterns (e.g. \"DB_*\")")
+	runCmd.Flags().StringSlice("exclude", []string{}, "do not inject secrets whose keys match one of these glob patterns")
+	runCmd.Flags().String("strip-prefix", "", "remove this prefix from the keys of injected secrets")
+	runCmd.Flags().String("prefix", "", "add this prefix to the keys of injected secrets")
+	runCmd.Flags().StringSlice("map", []string{}, "rename secrets before they are injected (e.g. \"DATABASE_URL=DB_URL\"). Mapped keys are not prefixed")
+	runCmd.Flags().Bool("sanitize-keys", false, "rename keys that are not valid environment variable names (e.g. \"db.host\") to UPPER_SNAKE (e.g. \"DB_HOST\")")
+	runCmd.Flags().StringSlice("reserved-names", defaultReservedEnvVars, "secrets with these names are never injected")
+	runCmd.Flags().StringSlice("reserved-prefixes", defaultReservedEnvVarPrefixes, "secrets with names starting with these prefixes are never injected")
+	runCmd.Flags().Bool("clean-env", false, "start the process with an empty environment instead of inheriting the current one. Use --allow-env to keep selected variables")
+	runCmd.Flags().StringSlice("allow-env", []string{}, "inherited variables to keep with --clean-env, glob patterns are supported (e.g. \"PATH,LC_*\")")
+	runCmd.Flags().Bool("dry-run", false, "print which secrets would be injected, which inherited variables they override and which were dropped as reserved, without running the command or revealing values")
+	runCmd.Flags().String("restart", RESTART_POLICY_NO, "restart the process when it exits (no, on-failure, always). Secrets are fetched again before every restart")
+	runCmd.Flags().Int("restart-max", 0, "maximum number of restarts, 0 means no limit")
+	runCmd.Flags().Int("restart-delay", 1, "seconds to wait before the first restart. The delay doubles after every consecutive failure")
+	runCmd.Flags().Int("restart-max-delay", 60, "maximum seconds to wait between restarts")
+	runCmd.Flags().Int("crash-loop-threshold", 5, "stop restarting when the process has been restarted this many times within --crash-loop-window, 0 disables the check")
+	runCmd.Flags().Int("crash-loop-window", 60, "seconds in which --crash-loop-threshold restarts are treated as a crash loop. A process that runs longer than this resets the backoff")
+	runCmd.Flags().Bool("init", false, "act as the init process of a container: reap orphaned processes and forward only termination signals to the process group of the command")
+	runCmd.Flags().Int("init-stop-timeout", 10, "with --init, seconds the process group gets to exit after a termination signal before it is killed")
+	runCmd.Flags().Bool("mask-output", false, "replace the values of injected secrets, including their base64 and URL-encoded forms, with *** in the output of the process")
+	runCmd.Flags().String("procfile", "", "start every entry of this Procfile with the same secrets instead of a single command")
+	runCmd.Flags().StringArray("process-watch-keys", []string{}, "secrets a Procfile entry depends on, as name=GLOB[,GLOB]. In watch mode the entry is only reloaded when one of them changes")
+	runCmd.Flags().String("mount-dir", "", "write secrets to files in this directory instead of injecting them as environment variables. Use \"auto\" to create a private memory backed directory")
+	runCmd.Flags().String("mount-format", SECRETS_MOUNT_FORMAT_FILES, fmt.Sprintf("how secrets are written to the mount directory (%s). files writes one file per secret", strings.Join(getSecretsMountFormatNames(), ", ")))
+	runCmd.Flags().StringArray("dynamic-secret", []string{}, "lease a dynamic secret for the lifetime of the process and inject its credentials, given as slug[:PREFIX][:ttl]. The lease is renewed in the background and revoked on exit. Can be repeated")
 }
 
 // Will execute a single command and pass in the given secrets into the process
-func executeSingleCommandWithEnvs(args []string, secretsCount int, env []string) error {
+func executeSingleCommandWithEnvs(args []string, secretsCount int, env []string, output processOutput, initOptions initProcessOptions) error {
 	command := args[0]
 	argsForCommand := args[1:]
 
@@ -233,14 +740,14 @@ func executeSingleCommandWithEnvs(args []string, secretsCount int, env []string)
 
 	cmd := exec.Command(command, argsForCommand...)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = output.Stdout
+	cmd.Stderr = output.Stderr
 	cmd.Env = env
 
-	return execBasicCmd(cmd)
+	return execBasicCmd(cmd, initOptions)
 }
 
-func executeMultipleCommandWithEnvs(fullCommand string, secretsCount int, env []string) error {
+func executeMultipleCommandWithEnvs(fullCommand string, secretsCount int, env []string, output processOutput, initOptions initProcessOptions) error {
 	shell := [2]string{"sh", "-c"}
 	if runtime.GOOS == "windows" {
 		shell = [2]string{"cmd", "/C"}
@@ -253,17 +760,26 @@ func executeMultipleCommandWithEnvs(fullCommand string, secretsCount int, env []
 
 	cmd := exec.Command(shell[0], shell[1], fullCommand)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = output.Stdout
+	cmd.Stderr = output.Stderr
 	cmd.Env = env
 
 	log.Info().Msgf(color.GreenString("Injecting %v Infisical secrets into your application process", secretsCount))
 	log.Debug().Msgf("executing command: %s %s %s \n", shell[0], shell[1], fullCommand)
 
-	return execBasicCmd(cmd)
+	return execBasicCmd(cmd, initOptions)
 }
 
-func execBasicCmd(cmd *exec.Cmd) error {
+func execBasicCmd(cmd *exec.Cmd, initOptions initProcessOptions) error {
+	if initOptions.Enabled {
+		exitCode, err := util.RunCommandAsInit(cmd, initOptions.StopTimeout)
+		if err != nil {
+			return err
+		}
+		util.Exit(exitCode)
+		return nil
+	}
+
 	sigChannel := make(chan os.Signal, 1)
 	signal.Notify(sigChannel)
 
@@ -279,35 +795,32 @@ func execBasicCmd(cmd *exec.Cmd) error {
 	}()
 
 	if err := cmd.Wait(); err != nil {
-		_ = cmd.Process.Signal(os.Kill)
-		return fmt.Errorf("failed to wait for command termination: %v", err)
+		if _, ok := err.(*exec.ExitError); !ok {
+			_ = cmd.Process.Signal(os.Kill)
+			return fmt.Errorf("failed to wait for command termination: %v", err)
+		}
 	}
 
-	waitStatus := cmd.ProcessState.Sys().(syscall.WaitStatus)
-	os.Exit(waitStatus.ExitStatus())
+	util.Exit(util.GetExitCode(cmd.ProcessState))
 	return nil
 }
 
 func waitForExitCommand(cmd *exec.Cmd) (int, error) {
-	if err := cmd.Wait(); err != nil {
+	if err := util.WaitForCommand(cmd); err != nil {
 		// ignore errors
 		cmd.Process.Signal(os.Kill) // #nosec G104
 
 		if exitError, ok := err.(*exec.ExitError); ok {
-			return exitError.ExitCode(), exitError
+			return util.GetExitCode(exitError.ProcessState), exitError
 		}
 
 		return 2, err
 	}
 
-	waitStatus, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
-	if !ok {
-		return 2, fmt.Errorf("unexpected ProcessState type, expected syscall.WaitStatus, got %T", waitStatus)
-	}
-	return waitStatus.ExitStatus(), nil
+	return util.GetExitCode(cmd.ProcessState), nil
 }
 
-func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInterval int, request models.GetAllSecretsParameters, projectConfigDir string, secretOverriding bool, token *models.TokenDetails) {
+func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInterval int, requests []models.GetAllSecretsParameters, projectConfigDir string, secretOverriding bool, token *models.TokenDetails, injectionOptions secretInjectionOptions, reloadOptions hotReloadOptions, mount *secretsMount, restartPolicy *restartPolicy, output processOutput) {
 
 	var cmd *exec.Cmd
 	var err error
@@ -316,14 +829,34 @@ func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInt
 	var watchMutex sync.Mutex
 	var processMutex sync.Mutex
 	var beingTerminated = false
+	var beingRestarted = false
 	var currentETag string
+	var currentSecrets []models.SingleEnvironmentVariable
 
 	if err != nil {
 		util.HandleError(err, "Failed to fetch secrets")
 	}
 
+	if restartPolicy.Policy != RESTART_POLICY_NO {
+		restartPolicy.WatchForTermination()
+	}
+
+	if reloadOptions.SecretsFile != "" {
+		// the file holds the secrets in plaintext, so it must not outlive the CLI
+		util.RegisterExitCleanup(func() {
+			if err := os.Remove(reloadOptions.SecretsFile); err != nil && !os.IsNotExist(err) {
+				log.Debug().Err(err).Msgf("unable to remove secrets file [%s]", reloadOptions.SecretsFile)
+			}
+		})
+	}
+
+	recheckSecretsChannel := make(chan bool, 1)
+	recheckSecretsChannel <- true
+
 	runCommandWithWatcher := func(environmentVariables models.InjectableEnvironmentResult) {
 		currentETag = environmentVariables.ETag
+		currentSecrets = environmentVariables.Secrets
+		output.AddSecrets(environmentVariables.Secrets)
 		secretsFetchedAt := time.Now()
 		if secretsFetchedAt.After(lastSecretsFetch) {
 			lastSecretsFetch = secretsFetchedAt
@@ -332,26 +865,50 @@ func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInt
 		shouldRestartProcess := cmd != nil
 		// terminate the old process before starting a new one
 		if shouldRestartProcess {
+			runReloadHook("pre-reload", reloadOptions.PreReloadHook)
+
+			// the process stays up and is told to re-read the secrets file, so there is nothing to restart
+			if reloadOptions.Strategy == RELOAD_STRATEGY_SIGNAL {
+				if err := writeSecretsForReload(reloadOptions.SecretsFile, mount, environmentVariables.Secrets); err != nil {
+					log.Error().Err(err).Msg(color.HiMagentaString("[HOT RELOAD] Failed to write secrets, not reloading process"))
+					// forget the ETag so the reload is retried on the next check
+					currentETag = ""
+					return
+				}
+
+				log.Info().Msgf(color.HiMagentaString("[HOT RELOAD] Environment changes detected. Sending %v to PID %d...", reloadOptions.ReloadSignal, cmd.Process.Pid))
+				if e := cmd.Process.Signal(reloadOptions.ReloadSignal); e != nil {
+					log.Error().Err(e).Msgf(color.HiMagentaString("[HOT RELOAD] Failed to send %v", reloadOptions.ReloadSignal))
+				}
+
+				runReloadHook("post-reload", reloadOptions.PostReloadHook)
+				return
+			}
+
 			log.Info().Msg(color.HiMagentaString("[HOT RELOAD] Environment changes detected. Reloading process..."))
 			beingTerminated = true
 
-			log.Debug().Msgf(color.HiMagentaString("[HOT RELOAD] Sending SIGTERM to PID %d", cmd.Process.Pid))
-			if e := cmd.Process.Signal(syscall.SIGTERM); e != nil {
-				log.Error().Err(e).Msg(color.HiMagentaString("[HOT RELOAD] Failed to send SIGTERM"))
+			log.Debug().Msgf(color.HiMagentaString("[HOT RELOAD] Sending %v to PID %d", reloadOptions.StopSignal, cmd.Process.Pid))
+			if e := cmd.Process.Signal(reloadOptions.StopSignal); e != nil {
+				log.Error().Err(e).Msgf(color.HiMagentaString("[HOT RELOAD] Failed to send %v", reloadOptions.StopSignal))
 			}
-			// wait up to 10 sec for the process to exit
-			for i := 0; i < 10; i++ {
+
+			// wait up to the grace period for the process to exit
+			gracePeriodDeadline := time.Now().Add(reloadOptions.StopGracePeriod)
+			loggedStillWaiting := false
+			for time.Now().Before(gracePeriodDeadline) {
 				if !util.IsProcessRunning(cmd.Process) {
 					// process has been killed so we break out
 					break
 				}
-				if i == 5 {
+				if !loggedStillWaiting && time.Until(gracePeriodDeadline) < reloadOptions.StopGracePeriod/2 {
 					log.Debug().Msg(color.HiMagentaString("[HOT RELOAD] Still waiting for process exit status"))
+					loggedStillWaiting = true
 				}
-				time.Sleep(time.Second)
+				time.Sleep(100 * time.Millisecond)
 			}
 
-			// SIGTERM may not work on Windows so we try SIGKILL
+			// the stop signal may not work on Windows so we try SIGKILL
 			if util.IsProcessRunning(cmd.Process) {
 				log.Debug().Msg(color.HiMagentaString("[HOT RELOAD] Process still hasn't fully exited, attempting SIGKILL"))
 				if e := cmd.Process.Kill(); e != nil {
@@ -360,10 +917,11 @@ func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInt
 			}
 
 			cmd = nil
-		} else {
+		} else if !beingRestarted {
 			// If `cmd` is nil, we know this is the first time we are starting the process
 			log.Info().Msg(color.HiMagentaString("[HOT RELOAD] Watching for secret changes..."))
 		}
+		beingRestarted = false
 
 		processMutex.Lock()
 
@@ -376,14 +934,36 @@ func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInt
 		watcherWaitGroup.Add(1)
 
 		// start the process
+		if len(requests) > 1 {
+			logSecretSources(environmentVariables)
+		}
 		log.Info().Msgf(color.GreenString("Injecting %v Infisical secrets into your application process", environmentVariables.SecretsCount))
 
-		cmd, err = util.RunCommand(commandFlag, args, environmentVariables.Variables, false)
+		processEnvironment := environmentVariables.Variables
+		if mount != nil {
+			processEnvironment = mount.ProcessEnvironment(environmentVariables.InheritedVariables)
+		}
+
+		if err := writeSecretsForReload(reloadOptions.SecretsFile, mount, environmentVariables.Secrets); err != nil {
+			defer watcherWaitGroup.Done()
+			util.HandleError(err, "Unable to write secrets file")
+		}
+
+		if reloadOptions.SecretsFile != "" && reloadOptions.Strategy == RELOAD_STRATEGY_SIGNAL {
+			processEnvironment = append(processEnvironment, fmt.Sprintf("%s=%s", util.INFISICAL_SECRETS_FILE_ENV_NAME, reloadOptions.SecretsFile))
+		}
+
+		processStartedAt := time.Now()
+		cmd, err = util.RunCommandWithIO(commandFlag, args, processEnvironment, os.Stdin, output.Stdout, output.Stderr)
 		if err != nil {
 			defer watcherWaitGroup.Done()
 			util.HandleError(err)
 		}
 
+		if shouldRestartProcess {
+			runReloadHook("post-reload", reloadOptions.PostReloadHook)
+		}
+
 		go func() {
 			defer processMutex.Unlock()
 			defer watcherWaitGroup.Done()
@@ -401,14 +981,29 @@ func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInt
 					}
 				}
 
-				os.Exit(exitCode)
+				shouldRestart, delay := restartPolicy.NextRestart(exitCode, time.Since(processStartedAt))
+				if !shouldRestart {
+					util.Exit(exitCode)
+				}
+
+				// the restart goes through the watcher so the secrets are fetched again first
+				go func() {
+					if !restartPolicy.Wait(delay) {
+						util.Exit(exitCode)
+					}
+
+					watchMutex.Lock()
+					cmd = nil
+					currentETag = ""
+					beingRestarted = true
+					watchMutex.Unlock()
+
+					recheckSecretsChannel <- true
+				}()
 			}
 		}()
 	}
 
-	recheckSecretsChannel := make(chan bool, 1)
-	recheckSecretsChannel <- true
-
 	// a simple goroutine that triggers the recheckSecretsChan every watch interval (defaults to 10 seconds)
 	go func() {
 		for {
@@ -423,47 +1018,382 @@ func executeCommandWithWatchMode(commandFlag string, args []string, watchModeInt
 			watchMutex.Lock()
 			defer watchMutex.Unlock()
 
-			newEnvironmentVariables, err := fetchAndFormatSecretsForShell(request, projectConfigDir, secretOverriding, token)
+			newEnvironmentVariables, err := fetchAndFormatSecretsForShell(requests, projectConfigDir, secretOverriding, token, injectionOptions)
 			if err != nil {
 				log.Error().Err(err).Msg("[HOT RELOAD] Failed to fetch secrets")
 				return
 			}
 
-			if newEnvironmentVariables.ETag != currentETag {
-				runCommandWithWatcher(newEnvironmentVariables)
-			} else {
+			if newEnvironmentVariables.ETag == currentETag {
 				log.Debug().Msg("[HOT RELOAD] No changes detected in secrets, not reloading process")
+				return
+			}
+
+			// the first start and restarts after an exit always go ahead, only reloads of a running process are filtered
+			if cmd != nil {
+				diff := util.DiffSecrets(currentSecrets, newEnvironmentVariables.Secrets)
+				watchedDiff, err := util.FilterSecretsDiff(diff, reloadOptions.WatchKeys, reloadOptions.WatchIgnore)
+				if err != nil {
+					log.Error().Err(err).Msg("[HOT RELOAD] Failed to filter secret changes")
+					return
+				}
+
+				if len(util.SecretsDiffKeys(watchedDiff)) == 0 {
+					log.Info().Msgf(color.HiMagentaString("[HOT RELOAD] Secrets changed (%s), none of them are watched, not reloading process", util.DescribeSecretsDiff(diff)))
+					currentETag = newEnvironmentVariables.ETag
+					currentSecrets = newEnvironmentVariables.Secrets
+					// the running process can still read the new values, e.g. from a mounted secrets dir
+					output.AddSecrets(newEnvironmentVariables.Secrets)
+					return
+				}
+
+				log.Info().Msgf(color.HiMagentaString("[HOT RELOAD] Secrets changed: %s", util.DescribeSecretsDiff(diff)))
 			}
 
+			runCommandWithWatcher(newEnvironmentVariables)
+
 		}()
 	}
 }
 
-func fetchAndFormatSecretsForShell(request models.GetAllSecretsParameters, projectConfigDir string, secretOverriding bool, token *models.TokenDetails) (models.InjectableEnvironmentResult, error) {
+func newProcessOutput(maskOutput bool) processOutput {
+	if !maskOutput {
+		return processOutput{Stdout: os.Stdout, Stderr: os.Stderr}
+	}
+
+	masker := util.NewSecretMasker()
+	stdout := masker.NewWriter(os.Stdout)
+	stderr := masker.NewWriter(os.Stderr)
+
+	// output that was held back because it could have been the start of a secret is written before the CLI exits
+	util.RegisterExitCleanup(func() {
+		stdout.Flush()
+		stderr.Flush()
+	})
+
+	return processOutput{Stdout: stdout, Stderr: stderr, masker: masker}
+}
+
+// AddSecrets masks the values of the given secrets from now on
+func (o processOutput) AddSecrets(secrets []models.SingleEnvironmentVariable) {
+	if o.masker != nil {
+		o.masker.AddSecrets(secrets)
+	}
+}
+
+// getProcessEnvironment returns the environment the process is started with. When a mount directory is used the secrets are written to it first
+func getProcessEnvironment(injectableEnvironment models.InjectableEnvironmentResult, mount *secretsMount) ([]string, error) {
+	if mount == nil {
+		return injectableEnvironment.Variables, nil
+	}
+
+	if err := mount.Write(injectableEnvironment.Secrets); err != nil {
+		return nil, err
+	}
+
+	return mount.ProcessEnvironment(injectableEnvironment.InheritedVariables), nil
+}
+
+// executeCommandWithRestartPolicy runs the command and starts it again according to the restart policy whenever it exits.
+// Secrets are fetched again before every restart, falling back to the previous secrets if they cannot be fetched
+func executeCommandWithRestartPolicy(commandFlag string, args []string, injectableEnvironment models.InjectableEnvironmentResult, fetchEnvironment func() (models.InjectableEnvironmentResult, error), mount *secretsMount, restartPolicy *restartPolicy, output processOutput) {
+	restartPolicy.WatchForTermination()
+
+	for {
+		processEnvironment, err := getProcessEnvironment(injectableEnvironment, mount)
+		if err != nil {
+			util.HandleError(err, "Unable to write secrets to mount directory")
+		}
+
+		log.Info().Msgf(color.GreenString("Injecting %v Infisical secrets into your application process", injectableEnvironment.SecretsCount))
+
+		exitCode := 1
+		processStartedAt := time.Now()
+		cmd, err := util.RunCommandWithIO(commandFlag, args, processEnvironment, os.Stdin, output.Stdout, output.Stderr)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to execute command")
+		} else {
+			exitCode, _ = waitForExitCommand(cmd)
+		}
+
+		shouldRestart, delay := restartPolicy.NextRestart(exitCode, time.Since(processStartedAt))
+		if !shouldRestart {
+			util.Exit(exitCode)
+		}
+
+		if !restartPolicy.Wait(delay) {
+			util.Exit(exitCode)
+		}
+
+		newInjectableEnvironment, err := fetchEnvironment()
+		if err != nil {
+			log.Error().Err(err).Msg(color.YellowString("[RESTART] Failed to fetch secrets, restarting with the previously fetched secrets"))
+			continue
+		}
+		injectableEnvironment = newInjectableEnvironment
+		output.AddSecrets(injectableEnvironment.Secrets)
+	}
+}
+
+// WatchForTermination stops any further restarts as soon as the CLI is asked to terminate. The signal itself is still forwarded to the process
+func (p *restartPolicy) WatchForTermination() {
+	terminationSignals := make(chan os.Signal, 1)
+	signal.Notify(terminationSignals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+
+	go func() {
+		<-terminationSignals
+		log.Debug().Msg("[RESTART] Termination requested, the process will not be restarted")
+		close(p.terminated)
+	}()
+}
+
+// NextRestart reports whether a process that exited with exitCode after running for ranFor is restarted, and after what delay.
+// The delay doubles with every consecutive restart and is reset once the process stays up for the crash loop window, if there is one
+func (p *restartPolicy) NextRestart(exitCode int, ranFor time.Duration) (bool, time.Duration) {
+	select {
+	case <-p.terminated:
+		return false, 0
+	default:
+	}
+
+	if p.Policy == RESTART_POLICY_NO || (p.Policy == RESTART_POLICY_ON_FAILURE && exitCode == 0) {
+		return false, 0
+	}
+
+	if p.MaxRestarts > 0 && p.restartCount >= p.MaxRestarts {
+		log.Error().Msgf(color.YellowString("[RESTART] Process exited with code %d and has already been restarted %d times, giving up", exitCode, p.restartCount))
+		return false, 0
+	}
+
+	now := time.Now()
+	if p.CrashLoopThreshold > 0 {
+		recentRestarts := []time.Time{}
+		for _, restartedAt := range p.recentRestarts {
+			if now.Sub(restartedAt) < p.CrashLoopWindow {
+				recentRestarts = append(recentRestarts, restartedAt)
+			}
+		}
+		p.recentRestarts = recentRestarts
+
+		if len(p.recentRestarts) >= p.CrashLoopThreshold {
+			log.Error().Msgf(color.YellowString("[RESTART] Process is crash looping, it was restarted %d times in the last %v. Giving up", len(p.recentRestarts), p.CrashLoopWindow))
+			return false, 0
+		}
+	}
+
+	if p.CrashLoopWindow > 0 && ranFor >= p.CrashLoopWindow {
+		p.consecutiveFailures = 0
+	}
+
+	delay := p.InitialDelay
+	for i := 0; i < p.consecutiveFailures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+
+	p.consecutiveFailures++
+	p.restartCount++
+	p.recentRestarts = append(p.recentRestarts, now)
+
+	log.Info().Msgf(color.YellowString("[RESTART] Process exited with code %d, restarting in %v (restart %d)", exitCode, delay, p.restartCount))
+	return true, delay
+}
+
+// Wait sleeps for the restart delay. It returns false if the CLI is asked to terminate in the meantime
+func (p *restartPolicy) Wait(delay time.Duration) bool {
+	select {
+	case <-time.After(delay):
+		return true
+	case <-p.terminated:
+		return false
+	}
+}
+
+// logSecretSources logs which source supplied each injected key when secrets are layered from multiple sources
+func logSecretSources(injectableEnvironment models.InjectableEnvironmentResult) {
+	for _, secret := range injectableEnvironment.Secrets {
+		log.Info().Msgf("Secret [%s] supplied by source [%s]", secret.Key, injectableEnvironment.SourceByKey[secret.Key])
+	}
+}
+
+// runReloadHook runs a user supplied hook command. A failing hook is logged but never blocks the reload
+func runReloadHook(hookName string, hookCommand string) {
+	if hookCommand == "" {
+		return
+	}
+
+	log.Debug().Msgf(color.HiMagentaString("[HOT RELOAD] Running %s hook: %s", hookName, hookCommand))
+	if err := ExecuteCommandWithTimeout(hookCommand, 0); err != nil {
+		log.Error().Err(err).Msgf(color.HiMagentaString("[HOT RELOAD] The %s hook failed", hookName))
+	}
+}
+
+// writeSecretsForReload writes the secrets to the reload secrets file and the mount directory, whichever are in use,
+// so a running process can re-read them on the reload signal
+func writeSecretsForReload(secretsFile string, mount *secretsMount, secrets []models.SingleEnvironmentVariable) error {
+	if mount != nil {
+		if err := mount.Write(secrets); err != nil {
+			return err
+		}
+	}
+
+	if secretsFile == "" {
+		return nil
+	}
+
+	return util.WriteFileAtomically(secretsFile, []byte(formatAsDotEnv(secrets)), 0600)
+}
 
-	if token != nil && token.Type == util.SERVICE_TOKEN_IDENTIFIER {
-		request.InfisicalToken = token.Token
-	} else if token != nil && token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER {
-		request.UniversalAuthAccessToken = token.Token
+// getSecretsMountFormatNames returns files and the export formats that can be written to the mount directory. Kubernetes manifests need a name and are left out
+func getSecretsMountFormatNames() []string {
+	names := []string{SECRETS_MOUNT_FORMAT_FILES}
+	for _, name := range getSecretsFormatterNames() {
+		if _, isManifest := secretsFormatters[name].(kubernetesManifestFormatter); !isManifest {
+			names = append(names, name)
+		}
 	}
+	return names
+}
 
-	secrets, err := util.GetAllEnvironmentVariables(request, projectConfigDir)
+func newSecretsMount(mountDir string, format string) (*secretsMount, error) {
+	format = strings.ToLower(format)
+	if !slices.Contains(getSecretsMountFormatNames(), format) {
+		return nil, fmt.Errorf("invalid mount format %q. Available mount formats are [%s]", format, strings.Join(getSecretsMountFormatNames(), ", "))
+	}
 
+	dir, createdDir, err := util.PrepareSecretsMountDir(mountDir)
 	if err != nil {
-		return models.InjectableEnvironmentResult{}, err
+		return nil, err
 	}
 
-	if secretOverriding {
-		secrets = util.OverrideSecrets(secrets, util.SECRET_TYPE_PERSONAL)
+	mount := &secretsMount{
+		Dir:          dir,
+		Format:       format,
+		createdDir:   createdDir,
+		writtenFiles: map[string]bool{},
+	}
+
+	// the secrets must not outlive the process, even when it exits with an error or is signalled
+	util.RegisterExitCleanup(mount.Remove)
+
+	log.Debug().Msgf("writing secrets to mount directory [%s]", dir)
+	return mount, nil
+}
+
+// Write renders the secrets into the mount directory. Every file is replaced atomically and files of secrets that no longer exist are removed
+func (m *secretsMount) Write(secrets []models.SingleEnvironmentVariable) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	filesToWrite := map[string][]byte{}
+	if m.Format == SECRETS_MOUNT_FORMAT_FILES {
+		for _, secret := range secrets {
+			if !isValidSecretsMountFileName(secret.Key) {
+				util.PrintWarning(fmt.Sprintf("Infisical secret named [%v] cannot be used as a file name and has not been written to the mount directory", secret.Key))
+				continue
+			}
+			filesToWrite[secret.Key] = []byte(secret.Value)
+		}
 	} else {
-		secrets = util.OverrideSecrets(secrets, util.SECRET_TYPE_SHARED)
+		formatter := secretsFormatters[m.Format]
+		output, err := formatter.Format(secrets)
+		if err != nil {
+			return err
+		}
+		filesToWrite[formatter.FileName()] = []byte(output)
+	}
+
+	for fileName, content := range filesToWrite {
+		if err := util.WriteFileAtomically(filepath.Join(m.Dir, fileName), content, 0600); err != nil {
+			return fmt.Errorf("unable to write secret file [%s] [err=%v]", fileName, err)
+		}
+	}
+
+	for fileName := range m.writtenFiles {
+		if _, ok := filesToWrite[fileName]; !ok {
+			if err := os.Remove(filepath.Join(m.Dir, fileName)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("unable to remove stale secret file [%s] [err=%v]", fileName, err)
+			}
+		}
+	}
+
+	m.writtenFiles = map[string]bool{}
+	for fileName := range filesToWrite {
+		m.writtenFiles[fileName] = true
+	}
+
+	return nil
+}
+
+// Remove deletes the mount directory when it was created by the CLI, otherwise only the files that were written to it
+func (m *secretsMount) Remove() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.createdDir {
+		if err := os.RemoveAll(m.Dir); err != nil {
+			log.Error().Err(err).Msgf("Unable to remove secrets mount directory [%s]", m.Dir)
+		}
+		return
+	}
+
+	for fileName := range m.writtenFiles {
+		if err := os.Remove(filepath.Join(m.Dir, fileName)); err != nil && !os.IsNotExist(err) {
+			log.Error().Err(err).Msgf("Unable to remove secret file [%s]", fileName)
+		}
+	}
+}
+
+// ProcessEnvironment returns the environment for a process reading its secrets from the mount directory.
+// Secrets are not injected, only the location of the mount directory is added
+func (m *secretsMount) ProcessEnvironment(inheritedVariables []string) []string {
+	processEnvironment := append([]string{}, inheritedVariables...)
+	return append(processEnvironment, fmt.Sprintf("%s=%s", util.INFISICAL_SECRETS_DIR_ENV_NAME, m.Dir))
+}
+
+func isValidSecretsMountFileName(key string) bool {
+	return key != "" && key != "." && key != ".." && !strings.ContainsAny(key, "/\\\x00")
+}
+
+func fetchAndFormatSecretsForShell(requests []models.GetAllSecretsParameters, projectConfigDir string, secretOverriding bool, token *models.TokenDetails, injectionOptions secretInjectionOptions) (models.InjectableEnvironmentResult, error) {
+
+	for i := range requests {
+		if token != nil && token.Type == util.SERVICE_TOKEN_IDENTIFIER {
+			requests[i].InfisicalToken = token.Token
+		} else if token != nil && token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER {
+			requests[i].UniversalAuthAccessToken = token.Token
+		}
+	}
+
+	mergedSecrets, err := util.GetAllEnvironmentVariablesFromSources(requests, projectConfigDir, secretOverriding)
+	if err != nil {
+		return models.InjectableEnvironmentResult{}, err
+	}
+
+	return buildInjectableEnvironment(mergedSecrets, injectionOptions)
+}
+
+// buildInjectableEnvironment renames the fetched secrets, drops the reserved ones and layers them over the inherited environment
+func buildInjectableEnvironment(mergedSecrets models.MergedSecretsResult, injectionOptions secretInjectionOptions) (models.InjectableEnvironmentResult, error) {
+	secrets, originalKeyByKey, err := util.TransformSecretKeys(mergedSecrets.Secrets, injectionOptions.KeyTransform)
+	if err != nil {
+		return models.InjectableEnvironmentResult{}, err
+	}
+
+	sourceByKey := make(map[string]string, len(secrets))
+	for key, originalKey := range originalKeyByKey {
+		sourceByKey[key] = mergedSecrets.SourceByKey[originalKey]
 	}
+	secrets = mergeDynamicSecrets(secrets, sourceByKey, injectionOptions.DynamicSecretLeases)
 
 	secretsByKey := getSecretsByKeys(secrets)
 	environmentVariables := make(map[string]string)
 
 	// add all existing environment vars
-	for _, s := range os.Environ() {
+	inheritedVariables := getInheritedEnvironment(injectionOptions)
+	for _, s := range inheritedVariables {
 		kv := strings.SplitN(s, "=", 2)
 		key := kv[0]
 		value := kv[1]
@@ -471,21 +1401,119 @@ func fetchAndFormatSecretsForShell(request models.GetAllSecretsParameters, proje
 	}
 
 	// check to see if there are any reserved key words in secrets to inject
-	filterReservedEnvVars(secretsByKey)
+	reservedKeys := filterReservedEnvVars(secretsByKey, injectionOptions.ReservedNames, injectionOptions.ReservedPrefixes)
 
 	// now add infisical secrets
+	overriddenKeys := []string{}
 	for k, v := range secretsByKey {
+		if _, ok := environmentVariables[k]; ok {
+			overriddenKeys = append(overriddenKeys, k)
+		}
 		environmentVariables[k] = v.Value
 	}
+	sort.Strings(overriddenKeys)
 
 	env := make([]string, 0, len(environmentVariables))
 	for key, value := range environmentVariables {
 		env = append(env, key+"="+value)
 	}
 
+	injectedSecrets := make([]models.SingleEnvironmentVariable, 0, len(secretsByKey))
+	for _, secret := range secretsByKey {
+		injectedSecrets = append(injectedSecrets, secret)
+	}
+
 	return models.InjectableEnvironmentResult{
 		Variables:    env,
-		ETag:         util.GenerateETagFromSecrets(secrets),
+		Secrets:      util.SortSecretsByKeys(injectedSecrets),
+		SourceByKey:  sourceByKey,
+		ETag:         mergedSecrets.ETag,
 		SecretsCount: len(secretsByKey),
+
+		InheritedVariables: inheritedVariables,
+		OverriddenKeys:     overriddenKeys,
+		ReservedKeys:       reservedKeys,
 	}, nil
 }
+
+// getInheritedEnvironment returns the variables of the CLI's own environment that are passed on to the process
+func getInheritedEnvironment(injectionOptions secretInjectionOptions) []string {
+	if !injectionOptions.CleanEnv {
+		return os.Environ()
+	}
+
+	inheritedVariables := []string{}
+	for _, s := range os.Environ() {
+		key := strings.SplitN(s, "=", 2)[0]
+		for _, pattern := range injectionOptions.AllowEnv {
+			if matched, _ := path.Match(pattern, key); matched {
+				inheritedVariables = append(inheritedVariables, s)
+				break
+			}
+		}
+	}
+
+	return inheritedVariables
+}
+
+// printDryRunEnvironment prints the names of the variables the process would receive, where they come from and what happened to them.
+// Values are never printed
+func printDryRunEnvironment(injectableEnvironment models.InjectableEnvironmentResult, dynamicSecretLeases []*dynamicSecretLease, mounted bool) {
+	rows, inheritedCount := getDryRunEnvironmentRows(injectableEnvironment, dynamicSecretLeases, mounted)
+
+	visualize.GenericTable([]string{"NAME", "SOURCE", "STATUS"}, rows)
+
+	if mounted {
+		fmt.Printf("%d secrets would be written to the mount directory, %d inherited variables would be passed through, %d secrets would be dropped as reserved\n", len(injectableEnvironment.Secrets), inheritedCount, len(injectableEnvironment.ReservedKeys))
+		return
+	}
+
+	fmt.Printf("%d secrets would be injected (%d overriding inherited variables), %d inherited variables would be passed through, %d secrets would be dropped as reserved\n", len(injectableEnvironment.Secrets), len(injectableEnvironment.OverriddenKeys), inheritedCount, len(injectableEnvironment.ReservedKeys))
+}
+
+// getDryRunEnvironmentRows returns the NAME, SOURCE and STATUS rows printed by a dry run, and the number of inherited variables passed through
+func getDryRunEnvironmentRows(injectableEnvironment models.InjectableEnvironmentResult, dynamicSecretLeases []*dynamicSecretLease, mounted bool) ([][]string, int) {
+	overriddenKeys := map[string]bool{}
+	for _, key := range injectableEnvironment.OverriddenKeys {
+		overriddenKeys[key] = true
+	}
+
+	rows := [][]string{}
+	for _, secret := range injectableEnvironment.Secrets {
+		status := "injected"
+		if mounted {
+			status = "written to mount directory"
+		} else if overriddenKeys[secret.Key] {
+			status = "injected, overrides inherited variable"
+		}
+		rows = append(rows, []string{secret.Key, "infisical", status})
+	}
+
+	for _, key := range injectableEnvironment.ReservedKeys {
+		rows = append(rows, []string{key, "infisical", "dropped, reserved name"})
+	}
+
+	// leases are not created in a dry run, so only the prefix of their variables is known
+	for _, lease := range dynamicSecretLeases {
+		rows = append(rows, []string{lease.Prefix + "*", "dynamic-secret:" + lease.Slug, "leased on start, revoked on exit"})
+	}
+
+	inheritedKeys := []string{}
+	for _, s := range injectableEnvironment.InheritedVariables {
+		key := strings.SplitN(s, "=", 2)[0]
+		if mounted || !overriddenKeys[key] {
+			inheritedKeys = append(inheritedKeys, key)
+		}
+	}
+	sort.Strings(inheritedKeys)
+
+	for _, key := range inheritedKeys {
+		rows = append(rows, []string{key, "inherited", "passed through"})
+	}
+
+	if mounted {
+		rows = append(rows, []string{util.INFISICAL_SECRETS_DIR_ENV_NAME, "infisical", "injected, path of the mount directory"})
+	}
+
+	return rows, len(inheritedKeys)
+}
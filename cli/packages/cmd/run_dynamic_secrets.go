@@ -0,0 +1,337 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/config"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/go-resty/resty/v2"
+	infisicalSdk "github.com/infisical/go-sdk"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// leases are renewed once this fraction of their remaining lifetime has passed
+	DYNAMIC_SECRET_RENEW_AFTER_FRACTION = 2.0 / 3.0
+	// failed renewals are retried after this delay, or sooner if the lease expires before
+	DYNAMIC_SECRET_RENEW_RETRY_DELAY = 30 * time.Second
+)
+
+var camelCaseBoundaryRegex = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// dynamicSecretLease is a lease created by `infisical run --dynamic-secret` for the lifetime of the process
+type dynamicSecretLease struct {
+	Slug     string
+	Prefix   string
+	TTL      string
+	LeaseId  string
+	ExpireAt time.Time
+	Secrets  []models.SingleEnvironmentVariable
+}
+
+// dynamicSecretLeaser creates the leases requested with --dynamic-secret, renews them in the background and revokes them on exit
+type dynamicSecretLeaser struct {
+	AccessToken string
+	ProjectSlug string
+	Environment string
+	SecretPath  string
+	Leases      []*dynamicSecretLease
+
+	infisicalClient infisicalSdk.InfisicalClientInterface
+	mutex           sync.Mutex
+	revoked         bool
+	stop            chan struct{}
+}
+
+// parseDynamicSecretFlag parses a --dynamic-secret flag given as slug[:PREFIX][:ttl].
+// Without a prefix, the data fields are prefixed with the slug of the dynamic secret
+func parseDynamicSecretFlag(value string) (*dynamicSecretLease, error) {
+	parts := strings.Split(value, ":")
+	if len(parts) > 3 || parts[0] == "" {
+		return nil, fmt.Errorf("invalid dynamic secret %q. Dynamic secrets must be in the form slug[:PREFIX][:ttl]", value)
+	}
+
+	lease := &dynamicSecretLease{Slug: parts[0]}
+	if len(parts) > 1 {
+		lease.Prefix = parts[1]
+	}
+	if len(parts) > 2 {
+		lease.TTL = parts[2]
+	}
+
+	if lease.Prefix == "" {
+		lease.Prefix = util.SanitizeEnvVarName(lease.Slug) + "_"
+	} else if !util.IsValidEnvVarName(lease.Prefix) {
+		return nil, fmt.Errorf("invalid prefix %q for dynamic secret [%s]. The prefix must be a valid environment variable name", lease.Prefix, lease.Slug)
+	}
+
+	return lease, nil
+}
+
+// getDynamicSecretLeaser parses the --dynamic-secret flags. It returns nil when no dynamic secret was requested.
+// Nothing is requested from Infisical until Authenticate is called
+func getDynamicSecretLeaser(dynamicSecrets []string, token *models.TokenDetails, environment string, secretPath string) (*dynamicSecretLeaser, error) {
+	if len(dynamicSecrets) == 0 {
+		return nil, nil
+	}
+
+	leaser := &dynamicSecretLeaser{
+		Environment: environment,
+		SecretPath:  secretPath,
+		stop:        make(chan struct{}),
+	}
+
+	leasesBySlug := map[string]bool{}
+	for _, dynamicSecret := range dynamicSecrets {
+		lease, err := parseDynamicSecretFlag(dynamicSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		if leasesBySlug[lease.Slug] {
+			return nil, fmt.Errorf("dynamic secret [%s] is requested more than once", lease.Slug)
+		}
+		leasesBySlug[lease.Slug] = true
+		leaser.Leases = append(leaser.Leases, lease)
+	}
+
+	if token != nil && token.Type == util.SERVICE_TOKEN_IDENTIFIER {
+		return nil, fmt.Errorf("dynamic secrets cannot be leased with a service token, please use a machine identity or log in")
+	}
+
+	return leaser, nil
+}
+
+// Authenticate resolves the access token and the project slug the leases are created with
+func (l *dynamicSecretLeaser) Authenticate(token *models.TokenDetails, projectId string) error {
+	if token != nil && token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER {
+		l.AccessToken = token.Token
+	} else {
+		util.RequireLogin()
+
+		loggedInUserDetails, err := util.GetCurrentLoggedInUserDetails(true)
+		if err != nil {
+			return fmt.Errorf("unable to authenticate [err=%v]", err)
+		}
+
+		if loggedInUserDetails.LoginExpired {
+			return fmt.Errorf("your login session has expired, please run [infisical login] and try again")
+		}
+		l.AccessToken = loggedInUserDetails.UserCredentials.JTWToken
+	}
+
+	if projectId == "" {
+		workspaceFile, err := util.GetWorkSpaceFromFile()
+		if err != nil {
+			return fmt.Errorf("unable to get local project details [err=%v]", err)
+		}
+		projectId = workspaceFile.WorkspaceId
+	}
+
+	httpClient := resty.New()
+	httpClient.SetAuthToken(l.AccessToken)
+
+	projectDetails, err := api.CallGetProjectById(httpClient, projectId)
+	if err != nil {
+		return fmt.Errorf("unable to fetch project details [err=%v]", err)
+	}
+	l.ProjectSlug = projectDetails.Slug
+
+	l.infisicalClient = infisicalSdk.NewInfisicalClient(context.Background(), infisicalSdk.Config{
+		SiteUrl:          config.INFISICAL_URL,
+		UserAgent:        api.USER_AGENT,
+		AutoTokenRefresh: false,
+	})
+	l.infisicalClient.Auth().SetAccessToken(l.AccessToken)
+
+	return nil
+}
+
+// Create leases every requested dynamic secret and registers their revocation on exit.
+// If one of the leases cannot be created, the leases created so far are revoked
+func (l *dynamicSecretLeaser) Create() error {
+	util.RegisterExitCleanup(l.RevokeAll)
+
+	for _, lease := range l.Leases {
+		createdLease, err := util.CreateDynamicSecretLease(l.AccessToken, l.ProjectSlug, l.Environment, l.SecretPath, lease.Slug, lease.TTL)
+		if err != nil {
+			l.RevokeAll()
+			return fmt.Errorf("unable to lease dynamic secret [%s] [err=%v]", lease.Slug, err)
+		}
+
+		l.mutex.Lock()
+		lease.LeaseId = createdLease.Lease.Id
+		lease.ExpireAt = createdLease.Lease.ExpireAt
+		l.mutex.Unlock()
+
+		lease.Secrets, err = getDynamicSecretLeaseEnvVars(lease.Prefix, createdLease.Data)
+		if err != nil {
+			l.RevokeAll()
+			return fmt.Errorf("unable to read the credentials of dynamic secret [%s] [err=%v]", lease.Slug, err)
+		}
+
+		log.Info().Msgf("Leased dynamic secret [%s] until %s", lease.Slug, lease.ExpireAt.Local().Format("02-Jan-2006 03:04:05 PM"))
+	}
+
+	return nil
+}
+
+// getDynamicSecretLeaseEnvVars turns the data fields of a lease into environment variables named PREFIX + UPPER_SNAKE(field)
+func getDynamicSecretLeaseEnvVars(prefix string, data map[string]interface{}) ([]models.SingleEnvironmentVariable, error) {
+	secrets := make([]models.SingleEnvironmentVariable, 0, len(data))
+	for field, value := range data {
+		var stringValue string
+		switch v := value.(type) {
+		case string:
+			stringValue = v
+		case nil:
+			stringValue = ""
+		default:
+			encodedValue, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			stringValue = string(encodedValue)
+		}
+
+		key := prefix + util.SanitizeEnvVarName(camelCaseBoundaryRegex.ReplaceAllString(field, "${1}_${2}"))
+		secrets = append(secrets, models.SingleEnvironmentVariable{Key: key, Value: stringValue, Type: util.SECRET_TYPE_SHARED})
+	}
+
+	return util.SortSecretsByKeys(secrets), nil
+}
+
+// mergeDynamicSecrets adds the credentials of the leases to the fetched secrets. Lease credentials win over fetched secrets with the same name
+func mergeDynamicSecrets(secrets []models.SingleEnvironmentVariable, sourceByKey map[string]string, leases []*dynamicSecretLease) []models.SingleEnvironmentVariable {
+	if len(leases) == 0 {
+		return secrets
+	}
+
+	leasedKeys := map[string]bool{}
+	for _, lease := range leases {
+		for _, secret := range lease.Secrets {
+			leasedKeys[secret.Key] = true
+			sourceByKey[secret.Key] = "dynamic-secret:" + lease.Slug
+		}
+	}
+
+	mergedSecrets := []models.SingleEnvironmentVariable{}
+	for _, secret := range secrets {
+		if !leasedKeys[secret.Key] {
+			mergedSecrets = append(mergedSecrets, secret)
+		}
+	}
+
+	for _, lease := range leases {
+		mergedSecrets = append(mergedSecrets, lease.Secrets...)
+	}
+
+	return mergedSecrets
+}
+
+// RenewInBackground renews every lease once most of its lifetime has passed, until the leases are revoked
+func (l *dynamicSecretLeaser) RenewInBackground() {
+	for _, lease := range l.Leases {
+		go l.renew(lease)
+	}
+}
+
+func (l *dynamicSecretLeaser) renew(lease *dynamicSecretLease) {
+	l.mutex.Lock()
+	expireAt := lease.ExpireAt
+	l.mutex.Unlock()
+
+	delay := time.Duration(float64(time.Until(expireAt)) * DYNAMIC_SECRET_RENEW_AFTER_FRACTION)
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-time.After(delay):
+		}
+
+		renewedLease, err := l.infisicalClient.DynamicSecrets().Leases().RenewById(infisicalSdk.RenewDynamicSecretLeaseOptions{
+			ProjectSlug:     l.ProjectSlug,
+			TTL:             lease.TTL,
+			SecretPath:      l.SecretPath,
+			EnvironmentSlug: l.Environment,
+			LeaseId:         lease.LeaseId,
+		})
+		if err != nil {
+			remaining := time.Until(expireAt)
+			if remaining <= 0 {
+				log.Error().Err(err).Msgf("Unable to renew the lease of dynamic secret [%s], the lease has expired", lease.Slug)
+				return
+			}
+
+			delay = DYNAMIC_SECRET_RENEW_RETRY_DELAY
+			if remaining/2 < delay {
+				delay = remaining / 2
+			}
+			log.Warn().Err(err).Msgf("Unable to renew the lease of dynamic secret [%s], retrying in %v", lease.Slug, delay.Round(time.Second))
+			continue
+		}
+
+		// the lease cannot be extended past the max ttl of the dynamic secret
+		newExpireAt := renewedLease.ExpireAt
+		if !newExpireAt.After(expireAt) {
+			log.Warn().Msgf("The lease of dynamic secret [%s] has reached its max ttl and expires at %s", lease.Slug, expireAt.Local().Format("02-Jan-2006 03:04:05 PM"))
+			return
+		}
+
+		l.mutex.Lock()
+		lease.ExpireAt = newExpireAt
+		l.mutex.Unlock()
+
+		log.Debug().Msgf("renewed the lease of dynamic secret [%s] until %v", lease.Slug, newExpireAt)
+
+		expireAt = newExpireAt
+		delay = time.Duration(float64(time.Until(expireAt)) * DYNAMIC_SECRET_RENEW_AFTER_FRACTION)
+	}
+}
+
+// RevokeAll stops the renewals and revokes every lease that was created. It only runs once
+func (l *dynamicSecretLeaser) RevokeAll() {
+	l.mutex.Lock()
+	if l.revoked {
+		l.mutex.Unlock()
+		return
+	}
+	l.revoked = true
+	close(l.stop)
+	l.mutex.Unlock()
+
+	for _, lease := range l.Leases {
+		l.mutex.Lock()
+		leaseId := lease.LeaseId
+		expireAt := lease.ExpireAt
+		l.mutex.Unlock()
+
+		if leaseId == "" {
+			continue
+		}
+
+		_, err := l.infisicalClient.DynamicSecrets().Leases().DeleteById(infisicalSdk.DeleteDynamicSecretLeaseOptions{
+			ProjectSlug:     l.ProjectSlug,
+			SecretPath:      l.SecretPath,
+			EnvironmentSlug: l.Environment,
+			LeaseId:         leaseId,
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("Unable to revoke the lease of dynamic secret [%s], it will expire at %s", lease.Slug, expireAt.Local().Format("02-Jan-2006 03:04:05 PM"))
+			continue
+		}
+		log.Debug().Msgf("revoked the lease of dynamic secret [%s]", lease.Slug)
+	}
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"testing"
+)
+
+func TestParseDynamicSecretFlag(t *testing.T) {
+	lease, err := parseDynamicSecretFlag("postgres-main")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lease.Slug != "postgres-main" || lease.Prefix != "POSTGRES_MAIN_" || lease.TTL != "" {
+		t.Errorf("Unexpected lease %+v", lease)
+	}
+
+	lease, err = parseDynamicSecretFlag("postgres-main:DB_:15m")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lease.Slug != "postgres-main" || lease.Prefix != "DB_" || lease.TTL != "15m" {
+		t.Errorf("Unexpected lease %+v", lease)
+	}
+
+	for _, value := range []string{"", ":DB_", "postgres-main:DB-:1h", "postgres-main:DB_:1h:extra"} {
+		if _, err := parseDynamicSecretFlag(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func TestGetDynamicSecretLeaseEnvVars(t *testing.T) {
+	secrets, err := getDynamicSecretLeaseEnvVars("DB_", map[string]interface{}{
+		"DB_USERNAME": "user",
+		"accessKeyId": "key",
+		"port":        5432,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := map[string]string{"DB_ACCESS_KEY_ID": "key", "DB_DB_USERNAME": "user", "DB_PORT": "5432"}
+	if len(secrets) != len(expected) {
+		t.Fatalf("Expected %d secrets, got %d", len(expected), len(secrets))
+	}
+	for _, secret := range secrets {
+		if expected[secret.Key] != secret.Value {
+			t.Errorf("Expected %s to be %q, got %q", secret.Key, expected[secret.Key], secret.Value)
+		}
+	}
+}
+
+func TestGetDynamicSecretLeaserWithoutAuthentication(t *testing.T) {
+	// parsing the flags must not log in or call the API, so --dry-run stays local
+	leaser, err := getDynamicSecretLeaser([]string{"postgres-main:DB_", "redis"}, nil, "dev", "/")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(leaser.Leases) != 2 || leaser.AccessToken != "" || leaser.ProjectSlug != "" {
+		t.Errorf("Unexpected leaser %+v", leaser)
+	}
+
+	if _, err := getDynamicSecretLeaser([]string{"redis", "redis:CACHE_"}, nil, "dev", "/"); err == nil {
+		t.Errorf("Expected an error for a dynamic secret requested twice")
+	}
+}
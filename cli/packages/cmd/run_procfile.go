@@ -0,0 +1,337 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/fatih/color"
+	"github.com/rs/zerolog/log"
+)
+
+var procfileColors = []color.Attribute{color.FgCyan, color.FgYellow, color.FgGreen, color.FgMagenta, color.FgBlue, color.FgRed}
+
+// procfileProcess is a single Procfile entry started by `infisical run --procfile`
+type procfileProcess struct {
+	Entry     models.ProcfileEntry
+	WatchKeys []string // glob patterns of the secrets the process depends on. Without any, every change affects the process
+
+	stdout     *util.PrefixedWriter
+	stderr     *util.PrefixedWriter
+	cmd        *exec.Cmd
+	exited     chan struct{}
+	restarting bool
+}
+
+type procfileExit struct {
+	Process  *procfileProcess
+	ExitCode int
+}
+
+// procfileRunner starts every Procfile entry with the same secrets and stops all of them as soon as one exits
+type procfileRunner struct {
+	Processes     []*procfileProcess
+	ReloadOptions hotReloadOptions
+
+	output processOutput
+	mutex  sync.Mutex
+	exits  chan procfileExit
+}
+
+// getProcfileProcesses parses the Procfile and the per process watch keys given as name=GLOB[,GLOB]
+func getProcfileProcesses(procfilePath string, processWatchKeys []string, output processOutput) ([]*procfileProcess, error) {
+	entries, err := util.ParseProcfile(procfilePath)
+	if err != nil {
+		return nil, err
+	}
+
+	outputMutex := &sync.Mutex{}
+	longestName := 0
+	for _, entry := range entries {
+		if len(entry.Name) > longestName {
+			longestName = len(entry.Name)
+		}
+	}
+
+	processes := []*procfileProcess{}
+	processesByName := map[string]*procfileProcess{}
+	for i, entry := range entries {
+		prefix := color.New(procfileColors[i%len(procfileColors)]).Sprintf("%-*s | ", longestName, entry.Name)
+		process := &procfileProcess{
+			Entry:  entry,
+			stdout: util.NewPrefixedWriter(output.Stdout, outputMutex, prefix),
+			stderr: util.NewPrefixedWriter(output.Stderr, outputMutex, prefix),
+		}
+		processes = append(processes, process)
+		processesByName[entry.Name] = process
+	}
+
+	for _, processWatchKey := range processWatchKeys {
+		name, patterns, found := strings.Cut(processWatchKey, "=")
+		if !found || patterns == "" {
+			return nil, fmt.Errorf("invalid process watch keys %q. Process watch keys must be in the form name=GLOB[,GLOB]", processWatchKey)
+		}
+
+		process, ok := processesByName[name]
+		if !ok {
+			return nil, fmt.Errorf("process watch keys given for [%s], which is not an entry of the Procfile", name)
+		}
+
+		for _, pattern := range strings.Split(patterns, ",") {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid process watch key pattern %q [err=%v]", pattern, err)
+			}
+			process.WatchKeys = append(process.WatchKeys, pattern)
+		}
+	}
+
+	return processes, nil
+}
+
+// isAffectedBy reports whether a change to any of the given secrets should reload the process
+func (p *procfileProcess) isAffectedBy(changedKeys []string) bool {
+	if len(p.WatchKeys) == 0 {
+		return len(changedKeys) > 0
+	}
+
+	for _, key := range changedKeys {
+		for _, pattern := range p.WatchKeys {
+			if matched, _ := path.Match(pattern, key); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// executeProcfile starts every Procfile entry and exits with the exit code of the first entry that exits.
+// In watch mode, only the entries affected by a change are reloaded
+func executeProcfile(processes []*procfileProcess, injectableEnvironment models.InjectableEnvironmentResult, watchMode bool, watchModeInterval int, fetchEnvironment func() (models.InjectableEnvironmentResult, error), reloadOptions hotReloadOptions, mount *secretsMount, output processOutput) {
+	runner := &procfileRunner{
+		Processes:     processes,
+		ReloadOptions: reloadOptions,
+		output:        output,
+		exits:         make(chan procfileExit, len(processes)),
+	}
+
+	processEnvironment, err := runner.getProcessEnvironment(injectableEnvironment, watchMode, mount)
+	if err != nil {
+		util.HandleError(err, "Unable to write secrets")
+	}
+
+	log.Info().Msgf(color.GreenString("Injecting %v Infisical secrets into %d processes", injectableEnvironment.SecretsCount, len(processes)))
+
+	for _, process := range processes {
+		if err := runner.start(process, processEnvironment); err != nil {
+			runner.stopAll()
+			util.HandleError(err, fmt.Sprintf("Unable to start process [%s]", process.Entry.Name))
+		}
+	}
+
+	if watchMode {
+		go runner.watch(injectableEnvironment, watchModeInterval, fetchEnvironment, mount)
+	}
+
+	firstExit := <-runner.exits
+	log.Info().Msgf("Process [%s] exited with code %d, stopping all processes", firstExit.Process.Entry.Name, firstExit.ExitCode)
+
+	runner.stopAll()
+	util.Exit(firstExit.ExitCode)
+}
+
+func (r *procfileRunner) getProcessEnvironment(injectableEnvironment models.InjectableEnvironmentResult, watchMode bool, mount *secretsMount) ([]string, error) {
+	processEnvironment, err := getProcessEnvironment(injectableEnvironment, mount)
+	if err != nil {
+		return nil, err
+	}
+
+	if watchMode && r.ReloadOptions.Strategy == RELOAD_STRATEGY_SIGNAL && r.ReloadOptions.SecretsFile != "" {
+		if err := writeSecretsForReload(r.ReloadOptions.SecretsFile, nil, injectableEnvironment.Secrets); err != nil {
+			return nil, err
+		}
+		processEnvironment = append(processEnvironment, fmt.Sprintf("%s=%s", util.INFISICAL_SECRETS_FILE_ENV_NAME, r.ReloadOptions.SecretsFile))
+	}
+
+	return processEnvironment, nil
+}
+
+func (r *procfileRunner) start(process *procfileProcess, processEnvironment []string) error {
+	log.Debug().Msgf("starting process [%s]: %s", process.Entry.Name, process.Entry.Command)
+
+	cmd, err := util.RunCommandWithIO(process.Entry.Command, nil, processEnvironment, nil, process.stdout, process.stderr)
+	if err != nil {
+		return err
+	}
+
+	exited := make(chan struct{})
+
+	r.mutex.Lock()
+	process.cmd = cmd
+	process.exited = exited
+	r.mutex.Unlock()
+
+	go func() {
+		exitCode, _ := waitForExitCommand(cmd)
+		process.stdout.Flush()
+		process.stderr.Flush()
+
+		r.mutex.Lock()
+		restarting := process.restarting
+		r.mutex.Unlock()
+		close(exited)
+
+		// an exit caused by a reload is not reported, the process is started again by the watcher
+		if !restarting {
+			r.exits <- procfileExit{Process: process, ExitCode: exitCode}
+		}
+	}()
+
+	return nil
+}
+
+// stop sends the stop signal to the process and kills it if it has not exited after the grace period
+func (r *procfileRunner) stop(process *procfileProcess) {
+	r.mutex.Lock()
+	cmd := process.cmd
+	exited := process.exited
+	r.mutex.Unlock()
+
+	if cmd == nil {
+		return
+	}
+
+	select {
+	case <-exited:
+		return
+	default:
+	}
+
+	log.Debug().Msgf("sending %v to process [%s]", r.ReloadOptions.StopSignal, process.Entry.Name)
+	if err := cmd.Process.Signal(r.ReloadOptions.StopSignal); err != nil {
+		log.Debug().Err(err).Msgf("unable to send %v to process [%s]", r.ReloadOptions.StopSignal, process.Entry.Name)
+	}
+
+	select {
+	case <-exited:
+	case <-time.After(r.ReloadOptions.StopGracePeriod):
+		log.Debug().Msgf("process [%s] has not exited after %v, attempting SIGKILL", process.Entry.Name, r.ReloadOptions.StopGracePeriod)
+		if err := cmd.Process.Kill(); err != nil {
+			log.Error().Err(err).Msgf("Unable to kill process [%s]", process.Entry.Name)
+		}
+		<-exited
+	}
+}
+
+func (r *procfileRunner) stopAll() {
+	waitGroup := sync.WaitGroup{}
+	for _, process := range r.Processes {
+		waitGroup.Add(1)
+		go func(process *procfileProcess) {
+			defer waitGroup.Done()
+			r.stop(process)
+		}(process)
+	}
+	waitGroup.Wait()
+}
+
+// watch fetches the secrets every watch interval and reloads the processes affected by a change
+func (r *procfileRunner) watch(injectableEnvironment models.InjectableEnvironmentResult, watchModeInterval int, fetchEnvironment func() (models.InjectableEnvironmentResult, error), mount *secretsMount) {
+	log.Info().Msg(color.HiMagentaString("[HOT RELOAD] Watching for secret changes..."))
+
+	for {
+		time.Sleep(time.Duration(watchModeInterval) * time.Second)
+
+		newInjectableEnvironment, err := fetchEnvironment()
+		if err != nil {
+			log.Error().Err(err).Msg("[HOT RELOAD] Failed to fetch secrets")
+			continue
+		}
+
+		if newInjectableEnvironment.ETag == injectableEnvironment.ETag {
+			log.Debug().Msg("[HOT RELOAD] No changes detected in secrets, not reloading processes")
+			continue
+		}
+
+		r.output.AddSecrets(newInjectableEnvironment.Secrets)
+
+		diff := util.DiffSecrets(injectableEnvironment.Secrets, newInjectableEnvironment.Secrets)
+		log.Info().Msgf(color.HiMagentaString("[HOT RELOAD] Secrets changed: %s", util.DescribeSecretsDiff(diff)))
+
+		watchedDiff, err := util.FilterSecretsDiff(diff, r.ReloadOptions.WatchKeys, r.ReloadOptions.WatchIgnore)
+		if err != nil {
+			log.Error().Err(err).Msg("[HOT RELOAD] Failed to filter secret changes")
+			continue
+		}
+		changedKeys := util.SecretsDiffKeys(watchedDiff)
+
+		affectedProcesses := []*procfileProcess{}
+		affectedNames := []string{}
+		for _, process := range r.Processes {
+			if process.isAffectedBy(changedKeys) {
+				affectedProcesses = append(affectedProcesses, process)
+				affectedNames = append(affectedNames, process.Entry.Name)
+			}
+		}
+
+		if len(affectedProcesses) == 0 {
+			log.Info().Msg(color.HiMagentaString("[HOT RELOAD] Environment changes detected, no process is affected"))
+			injectableEnvironment = newInjectableEnvironment
+			continue
+		}
+
+		runReloadHook("pre-reload", r.ReloadOptions.PreReloadHook)
+
+		processEnvironment, err := r.getProcessEnvironment(newInjectableEnvironment, true, mount)
+		if err != nil {
+			// keep the previous secrets so the reload is retried on the next check
+			log.Error().Err(err).Msg(color.HiMagentaString("[HOT RELOAD] Failed to write secrets, not reloading processes"))
+			continue
+		}
+
+		log.Info().Msgf(color.HiMagentaString("[HOT RELOAD] Environment changes detected. Reloading [%s]...", strings.Join(affectedNames, ", ")))
+		for _, process := range affectedProcesses {
+			r.reload(process, processEnvironment)
+		}
+
+		runReloadHook("post-reload", r.ReloadOptions.PostReloadHook)
+		injectableEnvironment = newInjectableEnvironment
+	}
+}
+
+// reload signals or restarts a single process, depending on the reload strategy
+func (r *procfileRunner) reload(process *procfileProcess, processEnvironment []string) {
+	if r.ReloadOptions.Strategy == RELOAD_STRATEGY_SIGNAL {
+		r.mutex.Lock()
+		cmd := process.cmd
+		r.mutex.Unlock()
+
+		if err := cmd.Process.Signal(r.ReloadOptions.ReloadSignal); err != nil {
+			log.Error().Err(err).Msgf(color.HiMagentaString("[HOT RELOAD] Failed to send %v to process [%s]", r.ReloadOptions.ReloadSignal, process.Entry.Name))
+		}
+		return
+	}
+
+	r.mutex.Lock()
+	process.restarting = true
+	r.mutex.Unlock()
+
+	r.stop(process)
+
+	r.mutex.Lock()
+	process.restarting = false
+	r.mutex.Unlock()
+
+	if err := r.start(process, processEnvironment); err != nil {
+		log.Error().Err(err).Msgf(color.HiMagentaString("[HOT RELOAD] Failed to restart process [%s]", process.Entry.Name))
+		r.exits <- procfileExit{Process: process, ExitCode: 1}
+	}
+}
@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecretsMountFiles(t *testing.T) {
+	mountDir := filepath.Join(t.TempDir(), "secrets")
+	mount, err := newSecretsMount(mountDir, SECRETS_MOUNT_FORMAT_FILES)
+	assert.NoError(t, err)
+	assert.Equal(t, mountDir, mount.Dir)
+
+	err = mount.Write([]models.SingleEnvironmentVariable{
+		{Key: "DB_PASSWORD", Value: "s3cr3t"},
+		{Key: "API_KEY", Value: "key"},
+		{Key: "../ESCAPE", Value: "nope"},
+	})
+	assert.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(mountDir, "DB_PASSWORD"))
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", string(content))
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(mountDir), "ESCAPE"))
+
+	if runtime.GOOS != "windows" {
+		dirInfo, err := os.Stat(mountDir)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0700), dirInfo.Mode().Perm())
+
+		fileInfo, err := os.Stat(filepath.Join(mountDir, "DB_PASSWORD"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), fileInfo.Mode().Perm())
+	}
+
+	// the file of a secret that no longer exists is removed
+	err = mount.Write([]models.SingleEnvironmentVariable{{Key: "DB_PASSWORD", Value: "rotated"}})
+	assert.NoError(t, err)
+	assert.NoFileExists(t, filepath.Join(mountDir, "API_KEY"))
+
+	content, err = os.ReadFile(filepath.Join(mountDir, "DB_PASSWORD"))
+	assert.NoError(t, err)
+	assert.Equal(t, "rotated", string(content))
+
+	// the directory was created by the CLI, so it is removed entirely
+	mount.Remove()
+	assert.NoDirExists(t, mountDir)
+}
+
+func TestSecretsMountExistingDir(t *testing.T) {
+	mountDir := t.TempDir()
+	mount, err := newSecretsMount(mountDir, "dotenv")
+	assert.NoError(t, err)
+
+	err = mount.Write([]models.SingleEnvironmentVariable{{Key: "DB_PASSWORD", Value: "s3cr3t"}})
+	assert.NoError(t, err)
+	dotenvFile := filepath.Join(mountDir, secretsFormatters["dotenv"].FileName())
+	assert.FileExists(t, dotenvFile)
+
+	// a directory that already existed is kept, only the written files are removed
+	mount.Remove()
+	assert.DirExists(t, mountDir)
+	assert.NoFileExists(t, dotenvFile)
+}
+
+func TestSecretsMountRejectsNonEmptyDir(t *testing.T) {
+	mountDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(mountDir, "unrelated"), []byte("data"), 0600))
+
+	_, err := newSecretsMount(mountDir, SECRETS_MOUNT_FORMAT_FILES)
+	assert.ErrorContains(t, err, "must be empty")
+
+	_, err = newSecretsMount(t.TempDir(), "k8s-secret")
+	assert.ErrorContains(t, err, "invalid mount format")
+}
+
+func testInjectableEnvironment(t *testing.T, injectionOptions secretInjectionOptions) models.InjectableEnvironmentResult {
+	injectionOptions.ReservedNames = defaultReservedEnvVars
+	injectionOptions.ReservedPrefixes = defaultReservedEnvVarPrefixes
+
+	injectableEnvironment, err := buildInjectableEnvironment(models.MergedSecretsResult{
+		Secrets: []models.SingleEnvironmentVariable{
+			{Key: "DB_PASSWORD", Value: "s3cr3t"},
+			{Key: "INFISICAL_TEST_OVERRIDDEN", Value: "from-infisical"},
+			{Key: "PATH", Value: "/infisical/bin"},
+		},
+		SourceByKey: map[string]string{},
+	}, injectionOptions)
+	assert.NoError(t, err)
+
+	return injectableEnvironment
+}
+
+func TestBuildInjectableEnvironment(t *testing.T) {
+	t.Setenv("INFISICAL_TEST_INHERITED", "inherited")
+	t.Setenv("INFISICAL_TEST_OVERRIDDEN", "inherited")
+
+	injectableEnvironment := testInjectableEnvironment(t, secretInjectionOptions{})
+	assert.Contains(t, injectableEnvironment.Variables, "DB_PASSWORD=s3cr3t")
+	assert.Contains(t, injectableEnvironment.Variables, "INFISICAL_TEST_INHERITED=inherited")
+	assert.Contains(t, injectableEnvironment.Variables, "INFISICAL_TEST_OVERRIDDEN=from-infisical")
+	assert.NotContains(t, injectableEnvironment.Variables, "PATH=/infisical/bin")
+	assert.Equal(t, []string{"INFISICAL_TEST_OVERRIDDEN"}, injectableEnvironment.OverriddenKeys)
+	assert.Equal(t, []string{"PATH"}, injectableEnvironment.ReservedKeys)
+	assert.Equal(t, 2, injectableEnvironment.SecretsCount)
+}
+
+func TestBuildInjectableEnvironmentCleanEnv(t *testing.T) {
+	t.Setenv("INFISICAL_TEST_INHERITED", "inherited")
+	t.Setenv("INFISICAL_TEST_OVERRIDDEN", "inherited")
+
+	injectableEnvironment := testInjectableEnvironment(t, secretInjectionOptions{CleanEnv: true})
+	assert.ElementsMatch(t, []string{"DB_PASSWORD=s3cr3t", "INFISICAL_TEST_OVERRIDDEN=from-infisical"}, injectableEnvironment.Variables)
+	assert.Empty(t, injectableEnvironment.InheritedVariables)
+	assert.Empty(t, injectableEnvironment.OverriddenKeys)
+
+	injectableEnvironment = testInjectableEnvironment(t, secretInjectionOptions{CleanEnv: true, AllowEnv: []string{"INFISICAL_TEST_IN*"}})
+	assert.ElementsMatch(t, []string{"DB_PASSWORD=s3cr3t", "INFISICAL_TEST_OVERRIDDEN=from-infisical", "INFISICAL_TEST_INHERITED=inherited"}, injectableEnvironment.Variables)
+	assert.Equal(t, []string{"INFISICAL_TEST_INHERITED=inherited"}, injectableEnvironment.InheritedVariables)
+}
+
+func TestGetDryRunEnvironmentRows(t *testing.T) {
+	t.Setenv("INFISICAL_TEST_OVERRIDDEN", "inherited")
+
+	injectableEnvironment := testInjectableEnvironment(t, secretInjectionOptions{CleanEnv: true, AllowEnv: []string{"INFISICAL_TEST_OVERRIDDEN"}})
+
+	rows, inheritedCount := getDryRunEnvironmentRows(injectableEnvironment, nil, false)
+	assert.Equal(t, [][]string{
+		{"DB_PASSWORD", "infisical", "injected"},
+		{"INFISICAL_TEST_OVERRIDDEN", "infisical", "injected, overrides inherited variable"},
+		{"PATH", "infisical", "dropped, reserved name"},
+	}, rows)
+	assert.Equal(t, 0, inheritedCount)
+
+	// with a mount directory the secrets are not injected, so the inherited variable is passed through as is
+	rows, inheritedCount = getDryRunEnvironmentRows(injectableEnvironment, nil, true)
+	assert.Equal(t, [][]string{
+		{"DB_PASSWORD", "infisical", "written to mount directory"},
+		{"INFISICAL_TEST_OVERRIDDEN", "infisical", "written to mount directory"},
+		{"PATH", "infisical", "dropped, reserved name"},
+		{"INFISICAL_TEST_OVERRIDDEN", "inherited", "passed through"},
+		{util.INFISICAL_SECRETS_DIR_ENV_NAME, "infisical", "injected, path of the mount directory"},
+	}, rows)
+	assert.Equal(t, 1, inheritedCount)
+}
+
+func TestRestartPolicyNextRestart(t *testing.T) {
+	type step struct {
+		exitCode int
+		ranFor   time.Duration
+		restart  bool
+		delay    time.Duration
+	}
+
+	tests := []struct {
+		name   string
+		policy restartPolicy
+		steps  []step
+	}{
+		{
+			name:   "no",
+			policy: restartPolicy{Policy: RESTART_POLICY_NO, InitialDelay: time.Second, MaxDelay: time.Minute},
+			steps:  []step{{exitCode: 1}, {exitCode: 0}},
+		},
+		{
+			name:   "on-failure",
+			policy: restartPolicy{Policy: RESTART_POLICY_ON_FAILURE, InitialDelay: time.Second, MaxDelay: time.Minute},
+			steps:  []step{{exitCode: 1, restart: true, delay: time.Second}, {exitCode: 0}},
+		},
+		{
+			name:   "always",
+			policy: restartPolicy{Policy: RESTART_POLICY_ALWAYS, InitialDelay: time.Second, MaxDelay: time.Minute},
+			steps:  []step{{exitCode: 0, restart: true, delay: time.Second}, {exitCode: 1, restart: true, delay: 2 * time.Second}},
+		},
+		{
+			name:   "max restarts",
+			policy: restartPolicy{Policy: RESTART_POLICY_ALWAYS, MaxRestarts: 2, InitialDelay: time.Second, MaxDelay: time.Minute},
+			steps: []step{
+				{exitCode: 1, restart: true, delay: time.Second},
+				{exitCode: 1, restart: true, delay: 2 * time.Second},
+				{exitCode: 1},
+			},
+		},
+		{
+			name:   "crash loop",
+			policy: restartPolicy{Policy: RESTART_POLICY_ALWAYS, CrashLoopThreshold: 2, CrashLoopWindow: time.Minute, InitialDelay: time.Second, MaxDelay: time.Minute},
+			steps: []step{
+				{exitCode: 1, restart: true, delay: time.Second},
+				{exitCode: 1, restart: true, delay: 2 * time.Second},
+				{exitCode: 1},
+			},
+		},
+		{
+			name:   "delay doubles up to the max delay",
+			policy: restartPolicy{Policy: RESTART_POLICY_ALWAYS, InitialDelay: time.Second, MaxDelay: 5 * time.Second},
+			steps: []step{
+				{exitCode: 1, restart: true, delay: time.Second},
+				{exitCode: 1, restart: true, delay: 2 * time.Second},
+				{exitCode: 1, restart: true, delay: 4 * time.Second},
+				{exitCode: 1, restart: true, delay: 5 * time.Second},
+				{exitCode: 1, restart: true, delay: 5 * time.Second},
+			},
+		},
+		{
+			name:   "delay resets once the process stays up for the crash loop window",
+			policy: restartPolicy{Policy: RESTART_POLICY_ALWAYS, CrashLoopWindow: 10 * time.Second, InitialDelay: time.Second, MaxDelay: time.Minute},
+			steps: []step{
+				{exitCode: 1, restart: true, delay: time.Second},
+				{exitCode: 1, restart: true, delay: 2 * time.Second},
+				{exitCode: 1, ranFor: 20 * time.Second, restart: true, delay: time.Second},
+			},
+		},
+		{
+			name:   "delay never resets without a crash loop window",
+			policy: restartPolicy{Policy: RESTART_POLICY_ALWAYS, InitialDelay: time.Second, MaxDelay: time.Minute},
+			steps: []step{
+				{exitCode: 1, ranFor: time.Hour, restart: true, delay: time.Second},
+				{exitCode: 1, ranFor: time.Hour, restart: true, delay: 2 * time.Second},
+				{exitCode: 1, ranFor: time.Hour, restart: true, delay: 4 * time.Second},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			policy := test.policy
+			policy.terminated = make(chan struct{})
+
+			for index, step := range test.steps {
+				restart, delay := policy.NextRestart(step.exitCode, step.ranFor)
+				assert.Equal(t, step.restart, restart, "step %d", index)
+				assert.Equal(t, step.delay, delay, "step %d", index)
+			}
+		})
+	}
+
+	policy := restartPolicy{Policy: RESTART_POLICY_ALWAYS, InitialDelay: time.Second, MaxDelay: time.Minute, terminated: make(chan struct{})}
+	close(policy.terminated)
+	restart, _ := policy.NextRestart(1, 0)
+	assert.False(t, restart, "a terminated CLI does not restart the process")
+}
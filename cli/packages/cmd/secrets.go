@@ -5,7 +5,10 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"regexp"
+	"slices"
 	"sort"
 	"strings"
 
@@ -139,11 +142,19 @@ var secretsGenerateExampleEnvCmd = &cobra.Command{
 }
 
 var secretsSetCmd = &cobra.Command{
-	Example:               `secrets set <secretName=secretValue> <secretName=secretValue>..."`,
+	Example: `
+	secrets set <secretName=secretValue> <secretName=secretValue>...
+	secrets set --file=.env
+	cat secrets.json | secrets set --file=- --file-format=json --dry-run`,
 	Short:                 "Used set secrets",
 	Use:                   "set [secrets]",
 	DisableFlagsInUseLine: true,
-	Args:                  cobra.MinimumNArgs(1),
+	Args: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !cmd.Flags().Changed("file") {
+			return fmt.Errorf("please provide the secrets to set as KEY=VALUE arguments, or a file of secrets with --file")
+		}
+		return nil
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		token, err := util.GetInfisicalToken(cmd)
 		if err != nil {
@@ -177,13 +188,56 @@ var secretsSetCmd = &cobra.Command{
 			util.HandleError(err, "Unable to parse secret type")
 		}
 
-		var secretOperations []models.SecretSetOperation
+		filePath, err := cmd.Flags().GetString("file")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		fileFormat, err := cmd.Flags().GetString("file-format")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		allowEmpty, err := cmd.Flags().GetBool("allow-empty")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		secretsToSet, err := getSecretsToSet(args, filePath, fileFormat)
+		if err != nil {
+			util.HandleError(err, "Unable to read secrets to set")
+		}
+
+		if err := util.ValidateSecretsToSet(secretsToSet, allowEmpty); err != nil {
+			util.PrintErrorMessageAndExit(err.Error())
+		}
+
+		atomic, err := cmd.Flags().GetBool("atomic")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if concurrency < 1 {
+			util.PrintErrorMessageAndExit("The --concurrency flag must be at least 1")
+		}
+
+		metadataChanges, tagSlugsToAdd, tagSlugsToRemove := getSecretMetadataChangesFromFlags(cmd)
+
+		tokenDetails := token
 		if token != nil && (token.Type == util.SERVICE_TOKEN_IDENTIFIER || token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER) {
 			if projectId == "" {
 				util.PrintErrorMessageAndExit("When using service tokens or machine identities, you must set the --projectId flag")
 			}
-
-			secretOperations, err = util.SetRawSecrets(args, secretType, environmentName, secretsPath, projectId, token)
 		} else {
 			if projectId == "" {
 				workspaceFile, err := util.GetWorkSpaceFromFile()
@@ -203,29 +257,145 @@ var secretsSetCmd = &cobra.Command{
 				util.PrintErrorMessageAndExit("Your login session has expired, please run [infisical login] and try again")
 			}
 
-			secretOperations, err = util.SetRawSecrets(args, secretType, environmentName, secretsPath, projectId, &models.TokenDetails{
+			tokenDetails = &models.TokenDetails{
 				Type:  "",
 				Token: loggedInUserDetails.UserCredentials.JTWToken,
-			})
+			}
 		}
 
-		if err != nil {
+		if len(tagSlugsToAdd) > 0 || len(tagSlugsToRemove) > 0 {
+			if tokenDetails.Type == util.SERVICE_TOKEN_IDENTIFIER {
+				util.PrintErrorMessageAndExit("Tags cannot be set with service tokens, please use a machine identity access token instead")
+			}
+
+			httpClient := resty.New().
+				SetAuthToken(tokenDetails.Token).
+				SetHeader("Accept", "application/json")
+
+			projectTags, err := api.CallGetProjectTagsV1(httpClient, projectId)
+			if err != nil {
+				util.HandleError(err, "Unable to fetch the tags of the project")
+			}
+
+			metadataChanges.AddTagIDs, err = util.GetTagIDsBySlugs(projectTags.WorkspaceTags, tagSlugsToAdd)
+			if err != nil {
+				util.PrintErrorMessageAndExit(err.Error())
+			}
+
+			metadataChanges.RemoveTagIDs, err = util.GetTagIDsBySlugs(projectTags.WorkspaceTags, tagSlugsToRemove)
+			if err != nil {
+				util.PrintErrorMessageAndExit(err.Error())
+			}
+		}
+
+		secretOperations, err := util.SetRawSecrets(secretsToSet, secretType, environmentName, secretsPath, projectId, tokenDetails, models.SetSecretsOptions{
+			DryRun:      dryRun,
+			Atomic:      atomic,
+			Concurrency: concurrency,
+			Metadata:    metadataChanges,
+		})
+		if err != nil && len(secretOperations) == 0 {
 			util.HandleError(err, "Unable to set secrets")
 		}
 
 		// Print secret operations
+		output := []secretSetOperationOutput{}
 		headers := [...]string{"SECRET NAME", "SECRET VALUE", "STATUS"}
 		rows := [][3]string{}
 		for _, secretOperation := range secretOperations {
+			operationOutput := secretSetOperationOutput{Key: secretOperation.SecretKey, Value: secretOperation.SecretValue, Status: secretOperation.SecretOperation}
+			if secretOperation.Error != nil {
+				operationOutput.Error = secretOperation.Error.Error()
+			}
+			output = append(output, operationOutput)
 			rows = append(rows, [...]string{secretOperation.SecretKey, secretOperation.SecretValue, secretOperation.SecretOperation})
 		}
 
-		visualize.Table(headers, rows)
+		visualize.Render(output, func() { visualize.Table(headers, rows) })
+
+		if dryRun {
+			util.PrintWarning("Dry run, no secrets were created or modified")
+		}
+
+		if err != nil {
+			for _, secretOperation := range secretOperations {
+				if secretOperation.Error != nil {
+					fmt.Fprintf(os.Stderr, "%s: %v\n", secretOperation.SecretKey, secretOperation.Error)
+				}
+			}
+			util.HandleError(err, "Unable to set secrets")
+		}
 
 		Telemetry.CaptureEvent("cli-command:secrets set", posthog.NewProperties().Set("version", util.CLI_VERSION))
 	},
 }
 
+// getSecretMetadataChangesFromFlags reads the comment and reminder to set from the flags of secrets set, along with the slugs of the
+// tags to add and remove, which are resolved to tag IDs once the project is known
+func getSecretMetadataChangesFromFlags(cmd *cobra.Command) (models.SecretMetadataChanges, []string, []string) {
+	metadataChanges := models.SecretMetadataChanges{}
+
+	if cmd.Flags().Changed("comment") {
+		comment, err := cmd.Flags().GetString("comment")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+		metadataChanges.Comment = &comment
+	}
+
+	if cmd.Flags().Changed("reminder-note") {
+		reminderNote, err := cmd.Flags().GetString("reminder-note")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+		metadataChanges.ReminderNote = &reminderNote
+	}
+
+	if cmd.Flags().Changed("reminder-repeat-days") {
+		reminderRepeatDays, err := cmd.Flags().GetInt("reminder-repeat-days")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if reminderRepeatDays < 1 || reminderRepeatDays > 365 {
+			util.PrintErrorMessageAndExit("The --reminder-repeat-days flag must be between 1 and 365")
+		}
+		metadataChanges.ReminderRepeatDays = &reminderRepeatDays
+	}
+
+	tagSlugsToAdd, err := cmd.Flags().GetStringSlice("tag")
+	if err != nil {
+		util.HandleError(err, "Unable to parse flag")
+	}
+
+	tagSlugsToRemove, err := cmd.Flags().GetStringSlice("remove-tag")
+	if err != nil {
+		util.HandleError(err, "Unable to parse flag")
+	}
+
+	for _, tagSlug := range tagSlugsToAdd {
+		if slices.Contains(tagSlugsToRemove, tagSlug) {
+			util.PrintErrorMessageAndExit(fmt.Sprintf("The tag [%s] cannot be both added with --tag and removed with --remove-tag", tagSlug))
+		}
+	}
+
+	return metadataChanges, tagSlugsToAdd, tagSlugsToRemove
+}
+
+// secretSetOperationOutput is the JSON and YAML schema of a secret written by secrets set
+type secretSetOperationOutput struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Status string `json:"status" yaml:"status"`
+	Error  string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// secretsDeleteOutput is the JSON and YAML schema of secrets delete
+type secretsDeleteOutput struct {
+	Deleted []string `json:"deleted" yaml:"deleted"`
+	Failed  []string `json:"failed" yaml:"failed"`
+}
+
 var secretsDeleteCmd = &cobra.Command{
 	Example:               `secrets delete <secret name A> <secret name B>..."`,
 	Short:                 "Used to delete secrets by name",
@@ -290,22 +460,35 @@ var secretsDeleteCmd = &cobra.Command{
 			httpClient.SetAuthToken(loggedInUserDetails.UserCredentials.JTWToken)
 		}
 
-		for _, secretName := range args {
-			request := api.DeleteSecretV3Request{
-				WorkspaceId: projectId,
-				Environment: environmentName,
-				SecretName:  secretName,
-				Type:        secretType,
-				SecretPath:  secretsPath,
-			}
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
 
-			err = api.CallDeleteSecretsRawV3(httpClient, request)
+		if concurrency < 1 {
+			util.PrintErrorMessageAndExit("The --concurrency flag must be at least 1")
+		}
+
+		deletedSecretNames := []string{}
+		failedSecretNames := []string{}
+		for index, err := range util.DeleteRawSecrets(httpClient, args, secretType, environmentName, secretsPath, projectId, concurrency) {
 			if err != nil {
-				util.HandleError(err, "Unable to complete your delete request")
+				fmt.Fprintf(os.Stderr, "%s: %v\n", args[index], err)
+				failedSecretNames = append(failedSecretNames, args[index])
+				continue
 			}
+			deletedSecretNames = append(deletedSecretNames, args[index])
 		}
 
-		fmt.Printf("secret name(s) [%v] have been deleted from your project \n", strings.Join(args, ", "))
+		visualize.Render(secretsDeleteOutput{Deleted: deletedSecretNames, Failed: failedSecretNames}, func() {
+			if len(deletedSecretNames) > 0 {
+				fmt.Printf("secret name(s) [%v] have been deleted from your project \n", strings.Join(deletedSecretNames, ", "))
+			}
+		})
+
+		if len(failedSecretNames) > 0 {
+			util.PrintErrorMessageAndExit(fmt.Sprintf("Unable to delete secret name(s) [%v]", strings.Join(failedSecretNames, ", ")))
+		}
 
 		Telemetry.CaptureEvent("cli-command:secrets delete", posthog.NewProperties().Set("secretCount", len(args)).Set("version", util.CLI_VERSION))
 	},
@@ -402,11 +585,14 @@ func getSecretsByNames(cmd *cobra.Command, args []string) {
 
 	secretsMap := getSecretsByKeys(secrets)
 
+	missingSecretNames := []string{}
 	for _, secretKeyFromArg := range args {
 		if value, ok := secretsMap[secretKeyFromArg]; ok {
 			requestedSecrets = append(requestedSecrets, value)
 		} else {
-			if !(plainOutput || showOnlyValue) {
+			missingSecretNames = append(missingSecretNames, secretKeyFromArg)
+			// JSON and YAML output only holds the secrets that exist, so it cannot be mistaken for their values
+			if !(plainOutput || showOnlyValue || visualize.IsStructuredOutput()) {
 				requestedSecrets = append(requestedSecrets, models.SingleEnvironmentVariable{
 					Key:   secretKeyFromArg,
 					Type:  "*not found*",
@@ -416,6 +602,10 @@ func getSecretsByNames(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if len(missingSecretNames) > 0 && visualize.IsStructuredOutput() {
+		util.PrintWarning(fmt.Sprintf("secret name(s) [%v] were not found", strings.Join(missingSecretNames, ", ")))
+	}
+
 	// showOnlyValue deprecated in favor of --plain, below only for backward compatibility
 	if plainOutput || showOnlyValue {
 		for _, secret := range requestedSecrets {
@@ -669,6 +859,53 @@ func getSecretsByKeys(secrets []models.SingleEnvironmentVariable) map[string]mod
 	return secretMapByName
 }
 
+// getSecretsToSet reads the secrets of the file, if any, followed by the KEY=VALUE arguments. When a key is given more than once, the last value wins
+func getSecretsToSet(args []string, filePath string, fileFormat string) ([]models.SingleEnvironmentVariable, error) {
+	secrets := []models.SingleEnvironmentVariable{}
+
+	if filePath != "" {
+		var content []byte
+		var err error
+		if filePath == "-" {
+			content, err = io.ReadAll(os.Stdin)
+		} else {
+			content, err = os.ReadFile(filePath)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read file [err=%v]", err)
+		}
+
+		if fileFormat == "" {
+			fileFormat = util.GetSecretsFileFormat(filePath)
+		}
+
+		secretsFromFile, err := util.ParseSecretsFile(string(content), fileFormat)
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, secretsFromFile...)
+	}
+
+	secretsFromArgs, err := util.ParseSecretArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	secrets = append(secrets, secretsFromArgs...)
+
+	indexByKey := make(map[string]int, len(secrets))
+	uniqueSecrets := []models.SingleEnvironmentVariable{}
+	for _, secret := range secrets {
+		if index, ok := indexByKey[secret.Key]; ok {
+			uniqueSecrets[index] = secret
+			continue
+		}
+		indexByKey[secret.Key] = len(uniqueSecrets)
+		uniqueSecrets = append(uniqueSecrets, secret)
+	}
+
+	return uniqueSecrets, nil
+}
+
 func init() {
 	secretsGenerateExampleEnvCmd.Flags().String("token", "", "Fetch secrets using service token or machine identity access token")
 	secretsGenerateExampleEnvCmd.Flags().String("projectId", "", "manually set the projectId when using machine identity based auth")
@@ -691,11 +928,23 @@ func init() {
 	secretsSetCmd.Flags().String("projectId", "", "manually set the project ID to for setting secrets when using machine identity based auth")
 	secretsSetCmd.Flags().String("path", "/", "set secrets within a folder path")
 	secretsSetCmd.Flags().String("type", util.SECRET_TYPE_SHARED, "the type of secret to create: personal or shared")
+	secretsSetCmd.Flags().String("file", "", "set the secrets of a .env, JSON or YAML file. Use - to read them from stdin")
+	secretsSetCmd.Flags().String("file-format", "", "the format of the --file: dotenv, json or yaml (default: from the file extension, dotenv for stdin)")
+	secretsSetCmd.Flags().Bool("dry-run", false, "show which secrets would be created, modified or left unchanged without writing them")
+	secretsSetCmd.Flags().Bool("allow-empty", false, "allow secrets to be set to an empty value")
+	secretsSetCmd.Flags().String("comment", "", "set the comment of the secrets. Use --comment=\"\" to remove it")
+	secretsSetCmd.Flags().StringSlice("tag", []string{}, "the slug of a tag to add to the secrets, can be repeated. See [infisical tags list]")
+	secretsSetCmd.Flags().StringSlice("remove-tag", []string{}, "the slug of a tag to remove from the secrets, can be repeated")
+	secretsSetCmd.Flags().Int("reminder-repeat-days", 0, "remind the project members to rotate the secrets every number of days")
+	secretsSetCmd.Flags().String("reminder-note", "", "the note sent with the rotation reminder of the secrets")
+	secretsSetCmd.Flags().Bool("atomic", false, "roll back the secrets that were written when any of the writes fails")
+	secretsSetCmd.Flags().Int("concurrency", util.DEFAULT_SECRET_WRITE_CONCURRENCY, "the maximum number of write requests sent at once")
 
 	secretsDeleteCmd.Flags().String("type", "personal", "the type of secret to delete: personal or shared  (default: personal)")
 	secretsDeleteCmd.Flags().String("token", "", "Fetch secrets using service token or machine identity access token")
 	secretsDeleteCmd.Flags().String("projectId", "", "manually set the projectId to delete secrets from when using machine identity based auth")
 	secretsDeleteCmd.Flags().String("path", "/", "get secrets within a folder path")
+	secretsDeleteCmd.Flags().Int("concurrency", util.DEFAULT_SECRET_WRITE_CONCURRENCY, "the maximum number of delete requests sent at once")
 	secretsCmd.AddCommand(secretsDeleteCmd)
 
 	// *** Folders sub command ***
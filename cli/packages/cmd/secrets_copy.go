@@ -0,0 +1,372 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
+	"github.com/posthog/posthog-go"
+	"github.com/spf13/cobra"
+)
+
+var secretsCopyCmd = &cobra.Command{
+	Example: `
+	infisical secrets copy --from staging:/svc --to prod:/svc --keys API_URL,FEATURE_FLAGS
+	infisical secrets copy --from staging --to prod --all --on-conflict=overwrite --dry-run
+	infisical secrets promote --from staging --to prod --all --recursive --missing-only`,
+	Short:                 "Used to copy secrets from one environment or path to another",
+	Use:                   "copy --from [env:path] --to [env:path] [--keys A,B | --all]",
+	Aliases:               []string{"promote"},
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		token, err := util.GetInfisicalToken(cmd)
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		from, err := cmd.Flags().GetString("from")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		to, err := cmd.Flags().GetString("to")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		projectId, err := cmd.Flags().GetString("projectId")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		fromProjectId, err := cmd.Flags().GetString("from-project")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		toProjectId, err := cmd.Flags().GetString("to-project")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		keys, err := cmd.Flags().GetStringSlice("keys")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		copyAll, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		conflictPolicy, err := cmd.Flags().GetString("on-conflict")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		missingOnly, err := cmd.Flags().GetBool("missing-only")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		recursive, err := cmd.Flags().GetBool("recursive")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if from == "" || to == "" {
+			util.PrintErrorMessageAndExit("Please provide where to copy secrets from and to with --from and --to, e.g. --from staging:/svc --to prod:/svc")
+		}
+
+		if len(keys) == 0 && !copyAll {
+			util.PrintErrorMessageAndExit("Please choose the secrets to copy with --keys, or copy all of them with --all")
+		}
+
+		if len(keys) > 0 && copyAll {
+			util.PrintErrorMessageAndExit("The --keys and --all flags cannot be used together")
+		}
+
+		if missingOnly {
+			if cmd.Flags().Changed("on-conflict") {
+				util.PrintErrorMessageAndExit("The --missing-only and --on-conflict flags cannot be used together")
+			}
+			conflictPolicy = util.SECRET_CONFLICT_POLICY_SKIP
+		}
+
+		if fromProjectId == "" {
+			fromProjectId = projectId
+		}
+		if toProjectId == "" {
+			toProjectId = projectId
+		}
+
+		tokenDetails := token
+		if token != nil && (token.Type == util.SERVICE_TOKEN_IDENTIFIER || token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER) {
+			if fromProjectId == "" || toProjectId == "" {
+				util.PrintErrorMessageAndExit("When using service tokens or machine identities, you must set the --projectId flag, or --from-project and --to-project")
+			}
+		} else {
+			if fromProjectId == "" || toProjectId == "" {
+				workspaceFile, err := util.GetWorkSpaceFromFile()
+				if err != nil {
+					util.HandleError(err, "Unable to get local project details")
+				}
+
+				if fromProjectId == "" {
+					fromProjectId = workspaceFile.WorkspaceId
+				}
+				if toProjectId == "" {
+					toProjectId = workspaceFile.WorkspaceId
+				}
+			}
+
+			util.RequireLogin()
+			loggedInUserDetails, err := util.GetCurrentLoggedInUserDetails(true)
+			if err != nil {
+				util.HandleError(err, "Unable to authenticate")
+			}
+
+			if loggedInUserDetails.LoginExpired {
+				util.PrintErrorMessageAndExit("Your login session has expired, please run [infisical login] and try again")
+			}
+
+			tokenDetails = &models.TokenDetails{Token: loggedInUserDetails.UserCredentials.JTWToken}
+		}
+
+		fromRequest, err := getSecretsLocationRequest(from, fromProjectId, token)
+		if err != nil {
+			util.HandleError(err, "Invalid --from")
+		}
+
+		toRequest, err := getSecretsLocationRequest(to, toProjectId, token)
+		if err != nil {
+			util.HandleError(err, "Invalid --to")
+		}
+
+		// secrets are copied as stored, without their references expanded and without the secrets imported into the folders
+		fromRequest.Recursive = recursive
+		toRequest.Recursive = recursive
+
+		sourceSecrets, err := getSecretsToCopy(fromRequest)
+		if err != nil {
+			util.HandleError(err, "Unable to fetch the secrets to copy")
+		}
+
+		if len(keys) > 0 {
+			sourceSecrets, err = filterSecretsToCopy(sourceSecrets, keys)
+			if err != nil {
+				util.HandleError(err)
+			}
+		}
+
+		targetFolders := []string{toRequest.SecretsPath}
+		for _, secret := range sourceSecrets {
+			targetFolder := path.Join(toRequest.SecretsPath, secret.SecretPath)
+			if !slices.Contains(targetFolders, targetFolder) {
+				targetFolders = append(targetFolders, targetFolder)
+			}
+		}
+
+		missingFolders, err := util.GetMissingFolders(models.GetAllFoldersParameters{
+			WorkspaceId:              toRequest.WorkspaceId,
+			Environment:              toRequest.Environment,
+			InfisicalToken:           toRequest.InfisicalToken,
+			UniversalAuthAccessToken: toRequest.UniversalAuthAccessToken,
+		}, targetFolders)
+		if err != nil {
+			util.HandleError(err, "Unable to check the folders of --to")
+		}
+
+		targetSecrets := []models.SingleEnvironmentVariable{}
+		if !slices.Contains(missingFolders, toRequest.SecretsPath) {
+			targetSecrets, err = getSecretsToCopy(toRequest)
+			if err != nil {
+				util.HandleError(err, "Unable to fetch the secrets of --to")
+			}
+		}
+
+		operations, err := util.PlanSecretsCopy(sourceSecrets, targetSecrets, conflictPolicy)
+		if err != nil {
+			util.HandleError(err)
+		}
+
+		conflictCount := 0
+		for _, operation := range operations {
+			if operation.Action == util.SECRET_COPY_CONFLICT {
+				conflictCount++
+			}
+		}
+
+		if conflictCount > 0 || dryRun {
+			printSecretCopyOperations(toRequest.SecretsPath, missingFolders, operations, false, nil)
+		}
+
+		if conflictCount > 0 {
+			util.PrintErrorMessageAndExit(fmt.Sprintf("%d secret(s) already exist in %s with a different value. Nothing was copied, use --on-conflict=overwrite or --on-conflict=skip to copy the other secrets", conflictCount, to))
+		}
+
+		if dryRun {
+			util.PrintWarning("Dry run, no folders or secrets were created or modified")
+			return
+		}
+
+		for _, missingFolder := range missingFolders {
+			_, err := util.CreateFolder(models.CreateFolderParameters{
+				FolderName:     path.Base(missingFolder),
+				FolderPath:     path.Dir(missingFolder),
+				WorkspaceId:    toRequest.WorkspaceId,
+				Environment:    toRequest.Environment,
+				InfisicalToken: tokenDetails.Token,
+			})
+			if err != nil {
+				util.HandleError(err, fmt.Sprintf("Unable to create folder [%s]", missingFolder))
+			}
+		}
+
+		secretsByFolder := map[string][]models.SingleEnvironmentVariable{}
+		folders := []string{}
+		for _, operation := range operations {
+			if operation.Action != util.SECRET_COPY_CREATE && operation.Action != util.SECRET_COPY_OVERWRITE {
+				continue
+			}
+			if _, ok := secretsByFolder[operation.SecretPath]; !ok {
+				folders = append(folders, operation.SecretPath)
+			}
+			secretsByFolder[operation.SecretPath] = append(secretsByFolder[operation.SecretPath], models.SingleEnvironmentVariable{Key: operation.Key, Value: operation.Value})
+		}
+
+		failedSecrets := map[string]error{}
+		for _, folder := range folders {
+			secretOperations, err := util.SetRawSecrets(secretsByFolder[folder], util.SECRET_TYPE_SHARED, toRequest.Environment, path.Join(toRequest.SecretsPath, folder), toRequest.WorkspaceId, tokenDetails, models.SetSecretsOptions{})
+			if err != nil && len(secretOperations) == 0 {
+				util.HandleError(err, fmt.Sprintf("Unable to copy secrets to folder [%s]", path.Join(toRequest.SecretsPath, folder)))
+			}
+
+			for _, secretOperation := range secretOperations {
+				if secretOperation.Error != nil {
+					failedSecrets[path.Join(folder, secretOperation.SecretKey)] = secretOperation.Error
+				}
+			}
+		}
+
+		printSecretCopyOperations(toRequest.SecretsPath, missingFolders, operations, true, failedSecrets)
+
+		Telemetry.CaptureEvent("cli-command:secrets copy", posthog.NewProperties().Set("secretCount", len(operations)).Set("version", util.CLI_VERSION))
+
+		if len(failedSecrets) > 0 {
+			for key, err := range failedSecrets {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", key, err)
+			}
+			util.PrintErrorMessageAndExit(fmt.Sprintf("%d secret(s) could not be copied", len(failedSecrets)))
+		}
+	},
+}
+
+// getSecretsToCopy fetches the shared secrets of a location, with their SecretPath relative to the location
+func getSecretsToCopy(request models.GetAllSecretsParameters) ([]models.SingleEnvironmentVariable, error) {
+	secrets, err := util.GetAllEnvironmentVariables(request, "")
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecrets := []models.SingleEnvironmentVariable{}
+	for _, secret := range secrets {
+		if secret.Type == util.SECRET_TYPE_PERSONAL {
+			continue
+		}
+		secret.SecretPath = util.GetRelativeSecretPath(request.SecretsPath, secret.SecretPath)
+		sharedSecrets = append(sharedSecrets, secret)
+	}
+	return sharedSecrets, nil
+}
+
+// filterSecretsToCopy keeps the secrets with the given keys, and fails when a key is not found in any folder
+func filterSecretsToCopy(secrets []models.SingleEnvironmentVariable, keys []string) ([]models.SingleEnvironmentVariable, error) {
+	filteredSecrets := []models.SingleEnvironmentVariable{}
+	foundKeys := map[string]bool{}
+	for _, secret := range secrets {
+		if slices.Contains(keys, secret.Key) {
+			filteredSecrets = append(filteredSecrets, secret)
+			foundKeys[secret.Key] = true
+		}
+	}
+
+	missingKeys := []string{}
+	for _, key := range keys {
+		if !foundKeys[key] {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	if len(missingKeys) > 0 {
+		return nil, fmt.Errorf("secret(s) [%s] not found in --from", strings.Join(missingKeys, ", "))
+	}
+
+	return filteredSecrets, nil
+}
+
+// secretsCopyOutput is the JSON and YAML schema of secrets copy
+type secretsCopyOutput struct {
+	// the folders that were created, or that would be created on a dry run
+	Folders []string           `json:"folders" yaml:"folders"`
+	Secrets []secretCopyOutput `json:"secrets" yaml:"secrets"`
+}
+
+type secretCopyOutput struct {
+	Folder string `json:"folder" yaml:"folder"`
+	Key    string `json:"key" yaml:"key"`
+	Action string `json:"action" yaml:"action"`
+}
+
+func printSecretCopyOperations(targetPath string, missingFolders []string, operations []models.SecretCopyOperation, written bool, failedSecrets map[string]error) {
+	output := secretsCopyOutput{Folders: append([]string{}, missingFolders...), Secrets: []secretCopyOutput{}}
+	headers := [...]string{"FOLDER", "SECRET NAME", "ACTION"}
+	rows := [][3]string{}
+	for _, operation := range operations {
+		action := operation.Action
+		if _, failed := failedSecrets[path.Join(operation.SecretPath, operation.Key)]; failed {
+			action = "failed"
+		}
+		output.Secrets = append(output.Secrets, secretCopyOutput{Folder: path.Join(targetPath, operation.SecretPath), Key: operation.Key, Action: action})
+		rows = append(rows, [...]string{path.Join(targetPath, operation.SecretPath), operation.Key, action})
+	}
+
+	visualize.Render(output, func() {
+		if len(missingFolders) > 0 && written {
+			fmt.Printf("Created folder(s): [%s]\n", strings.Join(missingFolders, ", "))
+		} else if len(missingFolders) > 0 {
+			fmt.Printf("Folder(s) to create: [%s]\n", strings.Join(missingFolders, ", "))
+		}
+
+		visualize.Table(headers, rows)
+	})
+}
+
+func init() {
+	secretsCopyCmd.Flags().String("from", "", "the environment and path to copy secrets from, in the form env:path, e.g. staging:/svc")
+	secretsCopyCmd.Flags().String("to", "", "the environment and path to copy secrets to, in the form env:path, e.g. prod:/svc")
+	secretsCopyCmd.Flags().String("token", "", "Fetch secrets using service token or machine identity access token")
+	secretsCopyCmd.Flags().String("projectId", "", "manually set the project ID of both sides when using machine identity based auth")
+	secretsCopyCmd.Flags().String("from-project", "", "the project ID to copy secrets from (default: --projectId or the project of the local config)")
+	secretsCopyCmd.Flags().String("to-project", "", "the project ID to copy secrets to (default: --projectId or the project of the local config)")
+	secretsCopyCmd.Flags().StringSlice("keys", []string{}, "the keys of the secrets to copy, e.g. API_URL,FEATURE_FLAGS")
+	secretsCopyCmd.Flags().Bool("all", false, "copy all secrets")
+	secretsCopyCmd.Flags().String("on-conflict", util.SECRET_CONFLICT_POLICY_FAIL, "what to do with secrets that exist in the target with a different value: skip, overwrite or fail")
+	secretsCopyCmd.Flags().Bool("missing-only", false, "only copy the secrets missing from the target, same as --on-conflict=skip")
+	secretsCopyCmd.Flags().Bool("recursive", false, "copy the secrets of all sub-folders, creating the folders missing from the target")
+	secretsCopyCmd.Flags().Bool("dry-run", false, "show what would be copied without writing anything")
+	secretsCmd.AddCommand(secretsCopyCmd)
+}
@@ -0,0 +1,322 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Infisical/infisical-merge/packages/config"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
+	"github.com/posthog/posthog-go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	SecretsDiffFormatTable = "table"
+	SecretsDiffFormatJson  = "json"
+	SecretsDiffFormatDiff  = "diff"
+)
+
+const (
+	// exit codes of secrets diff, following diff(1)
+	SECRETS_DIFF_EXIT_CODE_DIFFERENT = 1
+	SECRETS_DIFF_EXIT_CODE_ERROR     = 2
+)
+
+// secretsDiffOutput is the JSON and YAML schema of secrets diff
+type secretsDiffOutput struct {
+	From    string                    `json:"from" yaml:"from"`
+	To      string                    `json:"to" yaml:"to"`
+	Secrets []models.SecretComparison `json:"secrets" yaml:"secrets"`
+}
+
+var secretsDiffCmd = &cobra.Command{
+	Example: `
+	infisical secrets diff --from dev:/api --to prod:/api
+	infisical secrets diff --from staging --to prod --hash --format=json
+	infisical secrets diff --from dev --to dev --from-project <project id> --to-project <other project id>`,
+	Short:                 "Used to compare the secrets of two environments, paths or projects",
+	Use:                   "diff --from [env:path] --to [env:path]",
+	DisableFlagsInUseLine: true,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.NoArgs(cmd, args); err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		token, err := util.GetInfisicalToken(cmd)
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		from, err := cmd.Flags().GetString("from")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		to, err := cmd.Flags().GetString("to")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		projectId, err := cmd.Flags().GetString("projectId")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		fromProjectId, err := cmd.Flags().GetString("from-project")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		toProjectId, err := cmd.Flags().GetString("to-project")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		showValues, err := cmd.Flags().GetBool("show-values")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		hashValues, err := cmd.Flags().GetBool("hash")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		shouldExpandSecrets, err := cmd.Flags().GetBool("expand")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		includeImports, err := cmd.Flags().GetBool("include-imports")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		secretOverriding, err := cmd.Flags().GetBool("secret-overriding")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		tagSlugs, err := cmd.Flags().GetString("tags")
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		if from == "" || to == "" {
+			util.PrintErrorMessageAndExitWithCode(SECRETS_DIFF_EXIT_CODE_ERROR, "Please provide both sides to compare with --from and --to, e.g. --from dev:/api --to prod:/api")
+		}
+
+		if showValues && hashValues {
+			util.PrintErrorMessageAndExitWithCode(SECRETS_DIFF_EXIT_CODE_ERROR, "The --show-values and --hash flags cannot be used together")
+		}
+
+		if format != SecretsDiffFormatTable && format != SecretsDiffFormatJson && format != SecretsDiffFormatDiff {
+			util.PrintErrorMessageAndExitWithCode(SECRETS_DIFF_EXIT_CODE_ERROR, fmt.Sprintf("Invalid format %q. Available formats are [%s, %s, %s]", format, SecretsDiffFormatTable, SecretsDiffFormatJson, SecretsDiffFormatDiff))
+		}
+
+		if fromProjectId == "" {
+			fromProjectId = projectId
+		}
+		if toProjectId == "" {
+			toProjectId = projectId
+		}
+
+		if token != nil && (fromProjectId == "" || toProjectId == "") {
+			util.PrintErrorMessageAndExitWithCode(SECRETS_DIFF_EXIT_CODE_ERROR, "When using service tokens or machine identities, you must set the --projectId flag, or --from-project and --to-project")
+		}
+
+		if token == nil && (fromProjectId == "" || toProjectId == "") {
+			workspaceFile, err := util.GetWorkSpaceFromFile()
+			if err != nil || workspaceFile.WorkspaceId == "" {
+				util.PrintErrorMessageAndExitWithCode(SECRETS_DIFF_EXIT_CODE_ERROR, "It looks you have not yet connected this project to Infisical", "To do so, run [infisical init] then run your command again")
+			}
+		}
+
+		fromRequest, err := getSecretsLocationRequest(from, fromProjectId, token)
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Invalid --from")
+		}
+
+		toRequest, err := getSecretsLocationRequest(to, toProjectId, token)
+		if err != nil {
+			util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, "Invalid --to")
+		}
+
+		fromRequest.TagSlugs, toRequest.TagSlugs = tagSlugs, tagSlugs
+		fromRequest.IncludeImport, toRequest.IncludeImport = includeImports, includeImports
+		fromRequest.ExpandSecretReferences, toRequest.ExpandSecretReferences = shouldExpandSecrets, shouldExpandSecrets
+
+		fromSecrets := getSecretsToCompare(fromRequest, secretOverriding)
+		toSecrets := getSecretsToCompare(toRequest, secretOverriding)
+
+		comparisons := getPresentableSecretComparisons(util.CompareSecrets(fromSecrets, toSecrets), showValues, hashValues)
+
+		// --format=json is the same as --output=json
+		if format == SecretsDiffFormatJson {
+			config.OUTPUT_FORMAT = visualize.OutputFormatJson
+		}
+
+		if format == SecretsDiffFormatDiff {
+			fmt.Print(formatSecretComparisonsAsDiff(from, to, comparisons, showValues || hashValues))
+		} else {
+			output := secretsDiffOutput{From: from, To: to, Secrets: comparisons}
+			visualize.Render(output, func() { printSecretComparisons(comparisons, showValues || hashValues) })
+		}
+
+		Telemetry.CaptureEvent("cli-command:secrets diff", posthog.NewProperties().Set("secretCount", len(comparisons)).Set("version", util.CLI_VERSION))
+
+		for _, comparison := range comparisons {
+			if comparison.Status != util.SECRET_COMPARISON_IDENTICAL {
+				util.Exit(SECRETS_DIFF_EXIT_CODE_DIFFERENT)
+			}
+		}
+	},
+}
+
+// getSecretsLocationRequest parses a location of the form env[:path], as given to secrets diff and copy. The path defaults to the root folder
+func getSecretsLocationRequest(location string, projectId string, token *models.TokenDetails) (models.GetAllSecretsParameters, error) {
+	environment, secretsPath, found := strings.Cut(location, ":")
+	if environment == "" {
+		return models.GetAllSecretsParameters{}, fmt.Errorf("%q has no environment. Use the form env:path, e.g. dev:/api", location)
+	}
+	if !found || secretsPath == "" {
+		secretsPath = "/"
+	}
+	if !strings.HasPrefix(secretsPath, "/") {
+		secretsPath = "/" + secretsPath
+	}
+
+	request := models.GetAllSecretsParameters{
+		Environment: environment,
+		SecretsPath: secretsPath,
+		WorkspaceId: projectId,
+	}
+
+	if token != nil && token.Type == util.SERVICE_TOKEN_IDENTIFIER {
+		request.InfisicalToken = token.Token
+	} else if token != nil && token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER {
+		request.UniversalAuthAccessToken = token.Token
+	}
+
+	return request, nil
+}
+
+func getSecretsToCompare(request models.GetAllSecretsParameters, secretOverriding bool) []models.SingleEnvironmentVariable {
+	secrets, err := util.GetAllEnvironmentVariables(request, "")
+	if err != nil {
+		util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, fmt.Sprintf("Unable to fetch the secrets of environment [%s] and path [%s]", request.Environment, request.SecretsPath))
+	}
+
+	if secretOverriding {
+		return util.OverrideSecrets(secrets, util.SECRET_TYPE_PERSONAL)
+	}
+	return util.OverrideSecrets(secrets, util.SECRET_TYPE_SHARED)
+}
+
+// getPresentableSecretComparisons clears the values of the comparisons, unless they are shown as is or as hashes
+func getPresentableSecretComparisons(comparisons []models.SecretComparison, showValues bool, hashValues bool) []models.SecretComparison {
+	presentable := make([]models.SecretComparison, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		switch {
+		case hashValues:
+			comparison.FromValue = hashSecretComparisonValue(comparison.Status != util.SECRET_COMPARISON_EXTRA, comparison.FromValue)
+			comparison.ToValue = hashSecretComparisonValue(comparison.Status != util.SECRET_COMPARISON_MISSING, comparison.ToValue)
+		case !showValues:
+			comparison.FromValue = ""
+			comparison.ToValue = ""
+		}
+		presentable = append(presentable, comparison)
+	}
+	return presentable
+}
+
+func hashSecretComparisonValue(exists bool, value string) string {
+	if !exists {
+		return ""
+	}
+	hash := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(hash[:])[:16]
+}
+
+func printSecretComparisons(comparisons []models.SecretComparison, withValues bool) {
+	headers := []string{"SECRET NAME", "STATUS"}
+	if withValues {
+		headers = append(headers, "FROM", "TO")
+	}
+
+	rows := [][]string{}
+	for _, comparison := range comparisons {
+		row := []string{comparison.Key, comparison.Status}
+		if withValues {
+			row = append(row, comparison.FromValue, comparison.ToValue)
+		}
+		rows = append(rows, row)
+	}
+
+	visualize.GenericTable(headers, rows)
+}
+
+// formatSecretComparisonsAsDiff writes the comparisons in the style of a unified diff, with the identical secrets as context lines
+func formatSecretComparisonsAsDiff(from string, to string, comparisons []models.SecretComparison, withValues bool) string {
+	line := func(prefix string, key string, value string) string {
+		if !withValues {
+			return fmt.Sprintf("%s%s\n", prefix, key)
+		}
+		return fmt.Sprintf("%s%s=%s\n", prefix, key, value)
+	}
+
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %s\n+++ %s\n", from, to)
+	for _, comparison := range comparisons {
+		switch comparison.Status {
+		case util.SECRET_COMPARISON_MISSING:
+			diff.WriteString(line("-", comparison.Key, comparison.FromValue))
+		case util.SECRET_COMPARISON_EXTRA:
+			diff.WriteString(line("+", comparison.Key, comparison.ToValue))
+		case util.SECRET_COMPARISON_DIFFERENT:
+			if withValues {
+				diff.WriteString(line("-", comparison.Key, comparison.FromValue))
+				diff.WriteString(line("+", comparison.Key, comparison.ToValue))
+			} else {
+				diff.WriteString(line("~", comparison.Key, ""))
+			}
+		default:
+			diff.WriteString(line(" ", comparison.Key, comparison.ToValue))
+		}
+	}
+	return diff.String()
+}
+
+func init() {
+	secretsDiffCmd.Flags().String("from", "", "the environment and path to compare from, in the form env:path, e.g. dev:/api")
+	secretsDiffCmd.Flags().String("to", "", "the environment and path to compare to, in the form env:path, e.g. prod:/api")
+	secretsDiffCmd.Flags().String("token", "", "Fetch secrets using service token or machine identity access token")
+	secretsDiffCmd.Flags().String("projectId", "", "manually set the project ID of both sides when using machine identity based auth")
+	secretsDiffCmd.Flags().String("from-project", "", "the project ID of the --from side (default: --projectId or the project of the local config)")
+	secretsDiffCmd.Flags().String("to-project", "", "the project ID of the --to side (default: --projectId or the project of the local config)")
+	secretsDiffCmd.Flags().Bool("show-values", false, "show the values of the secrets")
+	secretsDiffCmd.Flags().Bool("hash", false, "show a SHA-256 prefix of the values instead of the values")
+	secretsDiffCmd.Flags().String("format", SecretsDiffFormatTable, "the output format: table, json or diff")
+	secretsDiffCmd.Flags().Bool("expand", true, "Parse shell parameter expansions in your secrets, and process your referenced secrets")
+	secretsDiffCmd.Flags().Bool("include-imports", true, "Imported linked secrets")
+	secretsDiffCmd.Flags().Bool("secret-overriding", true, "Prioritizes personal secrets, if any, with the same name over shared secrets")
+	secretsDiffCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		util.PrintErrorAndExit(SECRETS_DIFF_EXIT_CODE_ERROR, err, fmt.Sprintf("Run [%s --help] for usage", cmd.CommandPath()))
+		return err
+	})
+	secretsCmd.AddCommand(secretsDiffCmd)
+}
@@ -0,0 +1,237 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
+	"github.com/go-resty/resty/v2"
+	"github.com/posthog/posthog-go"
+	"github.com/spf13/cobra"
+)
+
+var secretsHistoryCmd = &cobra.Command{
+	Example: `
+	infisical secrets history DB_PASSWORD
+	infisical secrets history DB_PASSWORD --env=prod --path=/api --show-values`,
+	Short:                 "Used to list the versions of a secret",
+	Use:                   "history [secret name]",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		showValues, err := cmd.Flags().GetBool("show-values")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if limit < 1 {
+			util.PrintErrorMessageAndExit("The --limit flag must be at least 1")
+		}
+
+		request, secret, httpClient := getSecretToVersion(cmd, args[0])
+
+		versions, err := util.GetSecretVersions(httpClient, secret.ID, 0, limit)
+		if err != nil {
+			handleSecretVersionsError(err, request, "Unable to fetch the versions of the secret")
+		}
+
+		util.AddSecretVersionActors(httpClient, request.WorkspaceId, secret.ID, versions)
+
+		headers := []string{"VERSION", "CREATED AT", "ACTOR", "VALUE"}
+		rows := [][]string{}
+		for index, secretVersion := range versions {
+			if !showValues && secretVersion.Value != "" {
+				versions[index].Value = util.SECRET_MASK
+			}
+
+			actor := secretVersion.Actor
+			if actor == "" {
+				actor = "unknown"
+			}
+
+			rows = append(rows, []string{strconv.Itoa(secretVersion.Version), secretVersion.CreatedAt.Local().Format(time.RFC3339), actor, versions[index].Value})
+		}
+
+		visualize.Render(versions, func() { visualize.GenericTable(headers, rows) })
+
+		Telemetry.CaptureEvent("cli-command:secrets history", posthog.NewProperties().Set("versionCount", len(versions)).Set("version", util.CLI_VERSION))
+	},
+}
+
+var secretsRollbackCmd = &cobra.Command{
+	Example: `
+	infisical secrets rollback DB_PASSWORD --version=3
+	infisical secrets rollback DB_PASSWORD --version=3 --env=prod --path=/api`,
+	Short:                 "Used to restore a secret to the value of one of its versions",
+	Use:                   "rollback [secret name] --version [version]",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		version, err := cmd.Flags().GetInt("version")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if version < 1 {
+			util.PrintErrorMessageAndExit("Please provide the version to restore with --version, e.g. --version=3. Run [infisical secrets history] to list the versions")
+		}
+
+		request, secret, httpClient := getSecretToVersion(cmd, args[0])
+
+		secretVersion, err := util.GetSecretVersion(httpClient, secret.ID, version)
+		if err != nil {
+			handleSecretVersionsError(err, request, "Unable to find the version to restore")
+		}
+
+		if secretVersion.Value == secret.Value {
+			util.PrintSuccessMessage(fmt.Sprintf("Secret [%s] already has the value of version %d, nothing to roll back", secret.Key, version))
+			return
+		}
+
+		err = api.CallUpdateRawSecretsV3(httpClient, api.UpdateRawSecretByNameV3Request{
+			SecretName:  secret.Key,
+			WorkspaceID: request.WorkspaceId,
+			Environment: request.Environment,
+			SecretPath:  request.SecretsPath,
+			SecretValue: secretVersion.Value,
+			Type:        secret.Type,
+		})
+		if err != nil {
+			util.HandleError(err, "Unable to roll back the secret")
+		}
+
+		util.PrintSuccessMessage(fmt.Sprintf("Secret [%s] was rolled back to the value of version %d", secret.Key, version))
+
+		Telemetry.CaptureEvent("cli-command:secrets rollback", posthog.NewProperties().Set("version", util.CLI_VERSION))
+	},
+}
+
+// getSecretToVersion resolves the environment, path, project and credentials of secrets history and rollback the same way as
+// secrets get, and looks up the secret by name. The secret is fetched as stored, without imports or expanded references
+func getSecretToVersion(cmd *cobra.Command, secretName string) (models.GetAllSecretsParameters, models.SingleEnvironmentVariable, *resty.Client) {
+	token, err := util.GetInfisicalToken(cmd)
+	if err != nil {
+		util.HandleError(err, "Unable to parse flag")
+	}
+
+	environmentName, _ := cmd.Flags().GetString("env")
+	if !cmd.Flags().Changed("env") {
+		environmentFromWorkspace := util.GetEnvFromWorkspaceFile()
+		if environmentFromWorkspace != "" {
+			environmentName = environmentFromWorkspace
+		}
+	}
+
+	projectId, err := cmd.Flags().GetString("projectId")
+	if err != nil {
+		util.HandleError(err, "Unable to parse flag")
+	}
+
+	secretsPath, err := cmd.Flags().GetString("path")
+	if err != nil {
+		util.HandleError(err, "Unable to parse flag")
+	}
+
+	secretType, err := cmd.Flags().GetString("type")
+	if err != nil || (secretType != util.SECRET_TYPE_SHARED && secretType != util.SECRET_TYPE_PERSONAL) {
+		util.HandleError(err, "Unable to parse secret type")
+	}
+
+	request := models.GetAllSecretsParameters{
+		Environment: environmentName,
+		WorkspaceId: projectId,
+		SecretsPath: secretsPath,
+	}
+
+	tokenDetails := token
+	if token != nil && (token.Type == util.SERVICE_TOKEN_IDENTIFIER || token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER) {
+		if projectId == "" {
+			util.PrintErrorMessageAndExit("When using service tokens or machine identities, you must set the --projectId flag")
+		}
+
+		if token.Type == util.SERVICE_TOKEN_IDENTIFIER {
+			request.InfisicalToken = token.Token
+		} else {
+			request.UniversalAuthAccessToken = token.Token
+		}
+	} else {
+		if projectId == "" {
+			util.RequireLocalWorkspaceFile()
+			workspaceFile, err := util.GetWorkSpaceFromFile()
+			if err != nil {
+				util.HandleError(err, "Unable to get local project details")
+			}
+
+			request.WorkspaceId = workspaceFile.WorkspaceId
+		}
+
+		util.RequireLogin()
+		loggedInUserDetails, err := util.GetCurrentLoggedInUserDetails(true)
+		if err != nil {
+			util.HandleError(err, "Unable to authenticate")
+		}
+
+		if loggedInUserDetails.LoginExpired {
+			util.PrintErrorMessageAndExit("Your login session has expired, please run [infisical login] and try again")
+		}
+
+		tokenDetails = &models.TokenDetails{Token: loggedInUserDetails.UserCredentials.JTWToken}
+	}
+
+	secrets, err := util.GetAllEnvironmentVariables(request, "")
+	if err != nil {
+		util.HandleError(err, "Unable to fetch secrets")
+	}
+
+	for _, secret := range secrets {
+		if secret.Key == secretName && secret.Type == secretType {
+			httpClient := resty.New().
+				SetAuthToken(tokenDetails.Token).
+				SetHeader("Accept", "application/json")
+
+			return request, secret, httpClient
+		}
+	}
+
+	util.PrintErrorMessageAndExit(fmt.Sprintf("There is no %s secret named [%s] in environment [%s] and path [%s]", secretType, secretName, request.Environment, request.SecretsPath))
+	return models.GetAllSecretsParameters{}, models.SingleEnvironmentVariable{}, nil
+}
+
+// handleSecretVersionsError explains that the versions of a secret are only available to users, as machine identities and service tokens are refused
+func handleSecretVersionsError(err error, request models.GetAllSecretsParameters, message string) {
+	var responseError *api.UnsuccessfulResponseError
+	if (request.InfisicalToken != "" || request.UniversalAuthAccessToken != "") && errors.As(err, &responseError) &&
+		(responseError.StatusCode == http.StatusUnauthorized || responseError.StatusCode == http.StatusForbidden) {
+		util.PrintErrorMessageAndExit("The versions of a secret can only be read when logged in. Run [infisical login] and try again without --token")
+	}
+
+	util.HandleError(err, message)
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{secretsHistoryCmd, secretsRollbackCmd} {
+		cmd.Flags().String("token", "", "Fetch secrets using service token or machine identity access token")
+		cmd.Flags().String("projectId", "", "manually set the project ID of the secret when using machine identity based auth")
+		cmd.Flags().String("path", "/", "the folder path of the secret")
+		cmd.Flags().String("type", util.SECRET_TYPE_SHARED, "the type of the secret: personal or shared")
+		secretsCmd.AddCommand(cmd)
+	}
+
+	secretsHistoryCmd.Flags().Bool("show-values", false, "show the values of the versions instead of masking them")
+	secretsHistoryCmd.Flags().Int("limit", 20, "the number of versions to list, newest first")
+	secretsRollbackCmd.Flags().Int("version", 0, "the version to restore, as listed by [infisical secrets history]")
+}
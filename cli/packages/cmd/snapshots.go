@@ -0,0 +1,394 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/config"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
+	"github.com/go-resty/resty/v2"
+	"github.com/manifoldco/promptui"
+	"github.com/posthog/posthog-go"
+	"github.com/spf13/cobra"
+)
+
+const (
+	SnapshotsDiffFormatTable = "table"
+	SnapshotsDiffFormatJson  = "json"
+)
+
+const (
+	// exit codes of snapshots diff, the same as those of secrets diff
+	SNAPSHOTS_DIFF_EXIT_CODE_DIFFERENT = 1
+	SNAPSHOTS_DIFF_EXIT_CODE_ERROR     = 2
+)
+
+// snapshotOutput is the JSON and YAML schema of a snapshot listed by snapshots list
+type snapshotOutput struct {
+	ID        string    `json:"id" yaml:"id"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+// snapshotDiffOutput is the JSON and YAML schema of snapshots diff
+type snapshotDiffOutput struct {
+	Snapshot    string                  `json:"snapshot" yaml:"snapshot"`
+	Environment string                  `json:"environment" yaml:"environment"`
+	Path        string                  `json:"path" yaml:"path"`
+	Changes     []models.SnapshotChange `json:"changes" yaml:"changes"`
+}
+
+var snapshotsCmd = &cobra.Command{
+	Example: `
+	infisical snapshots list --env=prod --path=/api
+	infisical snapshots diff <snapshot id> --path=/api
+	infisical snapshots restore <snapshot id>`,
+	Short:                 "Used to list, compare and restore the snapshots of a folder",
+	Use:                   "snapshots",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var snapshotsListCmd = &cobra.Command{
+	Example:               `infisical snapshots list --env=prod --path=/api`,
+	Short:                 "Used to list the snapshots of a folder, newest first",
+	Use:                   "list",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		environmentName, _ := cmd.Flags().GetString("env")
+		if !cmd.Flags().Changed("env") {
+			environmentFromWorkspace := util.GetEnvFromWorkspaceFile()
+			if environmentFromWorkspace != "" {
+				environmentName = environmentFromWorkspace
+			}
+		}
+
+		projectId, err := cmd.Flags().GetString("projectId")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		secretsPath, err := cmd.Flags().GetString("path")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		offset, err := cmd.Flags().GetInt("offset")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if limit < 1 || offset < 0 {
+			util.PrintErrorMessageAndExit("The --limit flag must be at least 1 and the --offset flag cannot be negative")
+		}
+
+		httpClient, token := getUserOrIdentityHttpClient(cmd, "Snapshots", 1)
+
+		if projectId == "" {
+			if token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER {
+				util.PrintErrorMessageAndExit("When using machine identities, you must set the --projectId flag")
+			}
+
+			workspaceFile, err := util.GetWorkSpaceFromFile()
+			if err != nil {
+				util.HandleError(err, "Unable to get local project details")
+			}
+			projectId = workspaceFile.WorkspaceId
+		}
+
+		response, err := api.CallGetSecretSnapshotsV1(httpClient, api.GetSecretSnapshotsV1Request{
+			WorkspaceID: projectId,
+			Environment: environmentName,
+			SecretPath:  secretsPath,
+			Offset:      offset,
+			Limit:       limit,
+		})
+		if err != nil {
+			util.HandleError(err, "Unable to fetch the snapshots")
+		}
+
+		output := []snapshotOutput{}
+		headers := []string{"SNAPSHOT ID", "CREATED AT"}
+		rows := [][]string{}
+		for _, snapshot := range response.SecretSnapshots {
+			output = append(output, snapshotOutput{ID: snapshot.ID, CreatedAt: snapshot.CreatedAt})
+			rows = append(rows, []string{snapshot.ID, snapshot.CreatedAt.Local().Format(time.RFC3339)})
+		}
+
+		visualize.Render(output, func() { visualize.GenericTable(headers, rows) })
+
+		Telemetry.CaptureEvent("cli-command:snapshots list", posthog.NewProperties().Set("snapshotCount", len(response.SecretSnapshots)).Set("version", util.CLI_VERSION))
+	},
+}
+
+var snapshotsDiffCmd = &cobra.Command{
+	Example: `
+	infisical snapshots diff <snapshot id> --path=/api
+	infisical snapshots diff <snapshot id> --show-values --format=json`,
+	Short:                 "Used to preview what restoring a snapshot would change",
+	Use:                   "diff [snapshot id]",
+	DisableFlagsInUseLine: true,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if err := cobra.ExactArgs(1)(cmd, args); err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err)
+		}
+		return nil
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		secretsPath, err := cmd.Flags().GetString("path")
+		if err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		showValues, err := cmd.Flags().GetBool("show-values")
+		if err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err, "Unable to parse flag")
+		}
+
+		if format != SnapshotsDiffFormatTable && format != SnapshotsDiffFormatJson {
+			util.PrintErrorMessageAndExitWithCode(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, fmt.Sprintf("Invalid format %q. Available formats are [%s, %s]", format, SnapshotsDiffFormatTable, SnapshotsDiffFormatJson))
+		}
+
+		httpClient, token := getUserOrIdentityHttpClient(cmd, "Snapshots", SNAPSHOTS_DIFF_EXIT_CODE_ERROR)
+
+		response, err := api.CallGetSecretSnapshotV1(httpClient, args[0])
+		if err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err, "Unable to fetch the snapshot")
+		}
+		snapshot := response.SecretSnapshot
+
+		if err := validateSnapshotPath(httpClient, snapshot.ProjectID, snapshot.Environment.Slug, secretsPath, snapshot.ID, snapshot.FolderID); err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err)
+		}
+
+		snapshotSecrets := []models.SingleEnvironmentVariable{}
+		for _, secretVersion := range snapshot.SecretVersions {
+			snapshotSecrets = append(snapshotSecrets, models.SingleEnvironmentVariable{Key: secretVersion.SecretKey, Value: secretVersion.SecretValue})
+		}
+
+		snapshotFolders := []string{}
+		for _, folderVersion := range snapshot.FolderVersion {
+			snapshotFolders = append(snapshotFolders, folderVersion.Name)
+		}
+
+		// the current secrets are compared as stored, the same way the snapshot holds them
+		currentSecrets, err := util.GetPlainTextSecretsV3(token.Token, snapshot.ProjectID, snapshot.Environment.Slug, secretsPath, false, false, "", false)
+		if err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err, fmt.Sprintf("Unable to fetch the secrets of environment [%s] and path [%s]", snapshot.Environment.Slug, secretsPath))
+		}
+
+		var folders []models.SingleFolder
+		if token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER {
+			folders, err = util.GetFoldersViaMachineIdentity(token.Token, snapshot.ProjectID, snapshot.Environment.Slug, secretsPath)
+		} else {
+			folders, err = util.GetFoldersViaJTW(token.Token, snapshot.ProjectID, snapshot.Environment.Slug, secretsPath)
+		}
+		if err != nil {
+			util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err, fmt.Sprintf("Unable to fetch the folders of environment [%s] and path [%s]", snapshot.Environment.Slug, secretsPath))
+		}
+
+		currentFolders := []string{}
+		for _, folder := range folders {
+			currentFolders = append(currentFolders, folder.Name)
+		}
+
+		changes := util.PlanSnapshotRestore(util.OverrideSecrets(currentSecrets.Secrets, util.SECRET_TYPE_SHARED), snapshotSecrets, currentFolders, snapshotFolders)
+		if !showValues {
+			for index := range changes {
+				changes[index].CurrentValue = ""
+				changes[index].SnapshotValue = ""
+			}
+		}
+
+		// --format=json is the same as --output=json
+		if format == SnapshotsDiffFormatJson {
+			config.OUTPUT_FORMAT = visualize.OutputFormatJson
+		}
+
+		output := snapshotDiffOutput{Snapshot: snapshot.ID, Environment: snapshot.Environment.Slug, Path: secretsPath, Changes: changes}
+		visualize.Render(output, func() {
+			if len(changes) == 0 {
+				util.PrintSuccessMessage(fmt.Sprintf("Restoring snapshot [%s] would not change the secrets of environment [%s] and path [%s]", snapshot.ID, snapshot.Environment.Slug, secretsPath))
+			} else {
+				printSnapshotChanges(changes, showValues)
+			}
+		})
+
+		Telemetry.CaptureEvent("cli-command:snapshots diff", posthog.NewProperties().Set("changeCount", len(changes)).Set("version", util.CLI_VERSION))
+
+		if len(changes) > 0 {
+			util.Exit(SNAPSHOTS_DIFF_EXIT_CODE_DIFFERENT)
+		}
+	},
+}
+
+var snapshotsRestoreCmd = &cobra.Command{
+	Example: `
+	infisical snapshots restore <snapshot id>
+	infisical snapshots restore <snapshot id> --yes`,
+	Short:                 "Used to restore a folder, including its sub-folders, to one of its snapshots",
+	Use:                   "restore [snapshot id]",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		skipConfirmation, err := cmd.Flags().GetBool("yes")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		httpClient, _ := getUserOrIdentityHttpClient(cmd, "Snapshots", 1)
+
+		response, err := api.CallGetSecretSnapshotV1(httpClient, args[0])
+		if err != nil {
+			util.HandleError(err, "Unable to fetch the snapshot")
+		}
+		snapshot := response.SecretSnapshot
+
+		if !skipConfirmation {
+			confirmed, err := confirmSnapshotRestorePrompt(snapshot.ID, snapshot.Environment.Slug)
+			if err != nil {
+				util.HandleError(err, "Unable to confirm the restore, use --yes to restore without confirmation")
+			}
+			if !confirmed {
+				util.PrintErrorMessageAndExit("The snapshot was not restored")
+			}
+		}
+
+		_, err = api.CallRollbackSecretSnapshotV1(httpClient, snapshot.ID)
+		if err != nil {
+			util.HandleError(err, "Unable to restore the snapshot")
+		}
+
+		util.PrintSuccessMessage(fmt.Sprintf("Restored the folder of snapshot [%s] in environment [%s] to its state of %s", snapshot.ID, snapshot.Environment.Slug, snapshot.CreatedAt.Local().Format(time.RFC3339)))
+
+		Telemetry.CaptureEvent("cli-command:snapshots restore", posthog.NewProperties().Set("version", util.CLI_VERSION))
+	},
+}
+
+// getUserOrIdentityHttpClient authenticates with the machine identity access token of --token, or with the logged in user.
+// The feature, e.g. Snapshots, names what is not available to service tokens, and the process exits with errorExitCode when it fails
+func getUserOrIdentityHttpClient(cmd *cobra.Command, feature string, errorExitCode int) (*resty.Client, *models.TokenDetails) {
+	token, err := util.GetInfisicalToken(cmd)
+	if err != nil {
+		util.PrintErrorAndExit(errorExitCode, err, "Unable to parse flag")
+	}
+
+	if token != nil && token.Type == util.SERVICE_TOKEN_IDENTIFIER {
+		util.PrintErrorMessageAndExitWithCode(errorExitCode, fmt.Sprintf("%s cannot be used with service tokens, please use a machine identity access token instead", feature))
+	}
+
+	if token == nil {
+		if !util.IsLoggedIn() {
+			util.PrintErrorMessageAndExitWithCode(errorExitCode, "You must be logged in to run this command. To login, run [infisical login]")
+		}
+		loggedInUserDetails, err := util.GetCurrentLoggedInUserDetails(true)
+		if err != nil {
+			util.PrintErrorAndExit(errorExitCode, err, "Unable to authenticate")
+		}
+
+		if loggedInUserDetails.LoginExpired {
+			util.PrintErrorMessageAndExitWithCode(errorExitCode, "Your login session has expired, please run [infisical login] and try again")
+		}
+
+		token = &models.TokenDetails{Token: loggedInUserDetails.UserCredentials.JTWToken}
+	}
+
+	httpClient := resty.New().
+		SetAuthToken(token.Token).
+		SetHeader("Accept", "application/json")
+
+	return httpClient, token
+}
+
+// validateSnapshotPath checks that the snapshot was taken of the folder at secretsPath, by comparing its folder with the folder of
+// the snapshots listed for that path. The path of a snapshot cannot be read from the snapshot itself
+func validateSnapshotPath(httpClient *resty.Client, projectId string, environment string, secretsPath string, snapshotId string, snapshotFolderId string) error {
+	response, err := api.CallGetSecretSnapshotsV1(httpClient, api.GetSecretSnapshotsV1Request{
+		WorkspaceID: projectId,
+		Environment: environment,
+		SecretPath:  secretsPath,
+		Limit:       1,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to fetch the snapshots of environment [%s] and path [%s] [err=%v]", environment, secretsPath, err)
+	}
+
+	if len(response.SecretSnapshots) == 0 || response.SecretSnapshots[0].FolderID != snapshotFolderId {
+		return fmt.Errorf("snapshot [%s] was not taken of path [%s] in environment [%s]. Set --path to the path the snapshot is listed for with [infisical snapshots list]", snapshotId, secretsPath, environment)
+	}
+
+	return nil
+}
+
+func confirmSnapshotRestorePrompt(snapshotId string, environment string) (bool, error) {
+	prompt := promptui.Select{
+		Label: fmt.Sprintf("Restore the folder of snapshot [%s] in environment [%s]? Secrets created since the snapshot will be deleted. Select[Yes/No]", snapshotId, environment),
+		Items: []string{"No", "Yes"},
+	}
+	_, result, err := prompt.Run()
+	if err != nil {
+		return false, err
+	}
+	return result == "Yes", nil
+}
+
+func printSnapshotChanges(changes []models.SnapshotChange, withValues bool) {
+	headers := []string{"NAME", "KIND", "CHANGE"}
+	if withValues {
+		headers = append(headers, "CURRENT VALUE", "SNAPSHOT VALUE")
+	}
+
+	rows := [][]string{}
+	for _, change := range changes {
+		row := []string{change.Name, change.Kind, change.Action}
+		if withValues {
+			row = append(row, change.CurrentValue, change.SnapshotValue)
+		}
+		rows = append(rows, row)
+	}
+
+	visualize.GenericTable(headers, rows)
+}
+
+func init() {
+	snapshotsListCmd.Flags().String("env", "dev", "Used to select the environment name on which actions should be taken on")
+	snapshotsListCmd.Flags().String("path", "/", "the folder path of the snapshots")
+	snapshotsListCmd.Flags().String("projectId", "", "manually set the project ID of the snapshots when using machine identity based auth")
+	snapshotsListCmd.Flags().Int("limit", 20, "the number of snapshots to list")
+	snapshotsListCmd.Flags().Int("offset", 0, "the number of newer snapshots to skip")
+	snapshotsCmd.AddCommand(snapshotsListCmd)
+
+	snapshotsDiffCmd.Flags().String("path", "/", "the folder path of the snapshot, as given to [infisical snapshots list]")
+	snapshotsDiffCmd.Flags().Bool("show-values", false, "show the current and snapshot values of the secrets")
+	snapshotsDiffCmd.Flags().String("format", SnapshotsDiffFormatTable, "the output format: table or json")
+	snapshotsDiffCmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		util.PrintErrorAndExit(SNAPSHOTS_DIFF_EXIT_CODE_ERROR, err, fmt.Sprintf("Run [%s --help] for usage", cmd.CommandPath()))
+		return err
+	})
+	snapshotsCmd.AddCommand(snapshotsDiffCmd)
+
+	snapshotsRestoreCmd.Flags().BoolP("yes", "y", false, "restore without asking for confirmation")
+	snapshotsCmd.AddCommand(snapshotsRestoreCmd)
+
+	snapshotsCmd.PersistentFlags().String("token", "", "Use a machine identity access token instead of the logged in user")
+	rootCmd.AddCommand(snapshotsCmd)
+}
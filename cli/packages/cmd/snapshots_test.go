@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/config"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSnapshotPath(t *testing.T) {
+	folderIdsByPath := map[string]string{"/": "root-folder", "/api": "api-folder"}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := api.GetSecretSnapshotsV1Response{SecretSnapshots: []api.SecretSnapshot{}}
+		if folderId, found := folderIdsByPath[r.URL.Query().Get("path")]; found {
+			response.SecretSnapshots = append(response.SecretSnapshots, api.SecretSnapshot{ID: "latest", FolderID: folderId})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	previousUrl := config.INFISICAL_URL
+	config.INFISICAL_URL = server.URL
+	t.Cleanup(func() { config.INFISICAL_URL = previousUrl })
+
+	httpClient := resty.New()
+
+	assert.NoError(t, validateSnapshotPath(httpClient, "project", "prod", "/api", "snapshot", "api-folder"))
+	assert.ErrorContains(t, validateSnapshotPath(httpClient, "project", "prod", "/", "snapshot", "api-folder"), "snapshot [snapshot] was not taken of path [/]")
+	assert.ErrorContains(t, validateSnapshotPath(httpClient, "project", "prod", "/worker", "snapshot", "api-folder"), "was not taken of path [/worker]")
+}
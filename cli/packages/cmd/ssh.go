@@ -15,6 +15,7 @@ import (
 	"github.com/Infisical/infisical-merge/packages/api"
 	"github.com/Infisical/infisical-merge/packages/config"
 	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
 	infisicalSdk "github.com/infisical/go-sdk"
 	infisicalSdkUtil "github.com/infisical/go-sdk/packages/util"
 	"github.com/spf13/cobra"
@@ -240,8 +241,8 @@ func issueCredentials(cmd *cobra.Command, args []string) {
 		util.HandleError(err, "Unable to parse addToAgent flag")
 	}
 
-	if outFilePath == "" && addToAgent == false {
-		util.PrintErrorMessageAndExit("You must provide either --outFilePath or --addToAgent flag to use this command")
+	if outFilePath == "" && addToAgent == false && !visualize.IsStructuredOutput() {
+		util.PrintErrorMessageAndExit("You must provide either --outFilePath, --addToAgent or --output flag to use this command")
 	}
 
 	var (
@@ -363,7 +364,9 @@ func issueCredentials(cmd *cobra.Command, args []string) {
 			util.HandleError(err, "Failed to write Signed Key to file")
 		}
 
-		fmt.Println("Successfully wrote SSH certificate to:", signedKeyPath)
+		if !visualize.IsStructuredOutput() {
+			fmt.Println("Successfully wrote SSH certificate to:", signedKeyPath)
+		}
 	}
 
 	// Add SSH credentials to the SSH agent if needed
@@ -372,10 +375,38 @@ func issueCredentials(cmd *cobra.Command, args []string) {
 		err := addCredentialsToAgent(creds.PrivateKey, creds.SignedKey)
 		if err != nil {
 			util.HandleError(err, "Failed to add keys to SSH agent")
-		} else {
+		} else if !visualize.IsStructuredOutput() {
 			fmt.Println("The SSH key and certificate have been successfully added to your ssh-agent.")
 		}
 	}
+
+	output := sshCredentialsOutput{
+		SerialNumber: creds.SerialNumber,
+		KeyAlgorithm: string(creds.KeyAlgorithm),
+		AddedToAgent: addToAgent,
+	}
+
+	// the keys are only printed when they are not written to files, so they do not end up in logs by accident
+	if outFilePath != "" {
+		output.PrivateKeyPath, output.PublicKeyPath, output.SignedKeyPath = privateKeyPath, publicKeyPath, signedKeyPath
+	} else {
+		output.PrivateKey, output.PublicKey, output.SignedKey = creds.PrivateKey, creds.PublicKey, creds.SignedKey
+	}
+
+	visualize.Render(output, func() {})
+}
+
+// sshCredentialsOutput is the JSON and YAML schema of issued SSH credentials
+type sshCredentialsOutput struct {
+	SerialNumber   string `json:"serialNumber" yaml:"serialNumber"`
+	KeyAlgorithm   string `json:"keyAlgorithm" yaml:"keyAlgorithm"`
+	PrivateKey     string `json:"privateKey,omitempty" yaml:"privateKey,omitempty"`
+	PublicKey      string `json:"publicKey,omitempty" yaml:"publicKey,omitempty"`
+	SignedKey      string `json:"signedKey,omitempty" yaml:"signedKey,omitempty"`
+	PrivateKeyPath string `json:"privateKeyPath,omitempty" yaml:"privateKeyPath,omitempty"`
+	PublicKeyPath  string `json:"publicKeyPath,omitempty" yaml:"publicKeyPath,omitempty"`
+	SignedKeyPath  string `json:"signedKeyPath,omitempty" yaml:"signedKeyPath,omitempty"`
+	AddedToAgent   bool   `json:"addedToAgent" yaml:"addedToAgent"`
 }
 
 func signKey(cmd *cobra.Command, args []string) {
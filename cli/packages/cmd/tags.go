@@ -0,0 +1,186 @@
+/*
+Copyright (c) 2023 Infisical Inc.
+*/
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
+	"github.com/posthog/posthog-go"
+	"github.com/spf13/cobra"
+)
+
+const DefaultTagColor = "#bec2c8"
+
+var tagSlugRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// tagOutput is the JSON and YAML schema of a tag listed by tags list
+type tagOutput struct {
+	ID    string `json:"id" yaml:"id"`
+	Slug  string `json:"slug" yaml:"slug"`
+	Name  string `json:"name" yaml:"name"`
+	Color string `json:"color" yaml:"color"`
+}
+
+var tagsCmd = &cobra.Command{
+	Example: `
+	infisical tags list
+	infisical tags create prod-only --color=#ff0000
+	infisical tags delete prod-only`,
+	Short:                 "Used to manage the tags of a project",
+	Use:                   "tags",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+var tagsListCmd = &cobra.Command{
+	Example:               `infisical tags list`,
+	Short:                 "Used to list the tags of a project",
+	Use:                   "list",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		httpClient, token := getUserOrIdentityHttpClient(cmd, "Tags", 1)
+		projectId := getTagsProjectId(cmd, token)
+
+		response, err := api.CallGetProjectTagsV1(httpClient, projectId)
+		if err != nil {
+			util.HandleError(err, "Unable to fetch the tags of the project")
+		}
+
+		output := []tagOutput{}
+		headers := []string{"TAG SLUG", "NAME", "COLOR", "ID"}
+		rows := [][]string{}
+		for _, tag := range response.WorkspaceTags {
+			output = append(output, tagOutput{ID: tag.ID, Slug: tag.Slug, Name: tag.Name, Color: tag.Color})
+			rows = append(rows, []string{tag.Slug, tag.Name, tag.Color, tag.ID})
+		}
+
+		visualize.Render(output, func() { visualize.GenericTable(headers, rows) })
+
+		Telemetry.CaptureEvent("cli-command:tags list", posthog.NewProperties().Set("tagCount", len(response.WorkspaceTags)).Set("version", util.CLI_VERSION))
+	},
+}
+
+var tagsCreateCmd = &cobra.Command{
+	Example: `
+	infisical tags create prod-only
+	infisical tags create prod-only --name="Production only" --color=#ff0000`,
+	Short:                 "Used to create a tag",
+	Use:                   "create [tag slug]",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		tagSlug := args[0]
+		if !tagSlugRegex.MatchString(tagSlug) {
+			util.PrintErrorMessageAndExit(fmt.Sprintf("The tag slug [%s] is invalid, it can only contain lowercase letters and numbers separated by hyphens, e.g. prod-only", tagSlug))
+		}
+
+		tagName, err := cmd.Flags().GetString("name")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		if tagName == "" {
+			tagName = tagSlug
+		}
+
+		color, err := cmd.Flags().GetString("color")
+		if err != nil {
+			util.HandleError(err, "Unable to parse flag")
+		}
+
+		httpClient, token := getUserOrIdentityHttpClient(cmd, "Tags", 1)
+		projectId := getTagsProjectId(cmd, token)
+
+		_, err = api.CallCreateProjectTagV1(httpClient, api.CreateProjectTagV1Request{
+			ProjectID: projectId,
+			Slug:      tagSlug,
+			Name:      tagName,
+			Color:     color,
+		})
+		if err != nil {
+			util.HandleError(err, "Unable to create the tag")
+		}
+
+		util.PrintSuccessMessage(fmt.Sprintf("Tag [%s] was created", tagSlug))
+
+		Telemetry.CaptureEvent("cli-command:tags create", posthog.NewProperties().Set("version", util.CLI_VERSION))
+	},
+}
+
+var tagsDeleteCmd = &cobra.Command{
+	Example:               `infisical tags delete prod-only`,
+	Short:                 "Used to delete a tag, which removes it from every secret",
+	Use:                   "delete [tag slug]",
+	DisableFlagsInUseLine: true,
+	Args:                  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		httpClient, token := getUserOrIdentityHttpClient(cmd, "Tags", 1)
+		projectId := getTagsProjectId(cmd, token)
+
+		response, err := api.CallGetProjectTagsV1(httpClient, projectId)
+		if err != nil {
+			util.HandleError(err, "Unable to fetch the tags of the project")
+		}
+
+		tagIDs, err := util.GetTagIDsBySlugs(response.WorkspaceTags, args[:1])
+		if err != nil {
+			util.PrintErrorMessageAndExit(err.Error())
+		}
+
+		_, err = api.CallDeleteProjectTagV1(httpClient, projectId, tagIDs[0])
+		if err != nil {
+			util.HandleError(err, "Unable to delete the tag")
+		}
+
+		util.PrintSuccessMessage(fmt.Sprintf("Tag [%s] was deleted", args[0]))
+
+		Telemetry.CaptureEvent("cli-command:tags delete", posthog.NewProperties().Set("version", util.CLI_VERSION))
+	},
+}
+
+// getTagsProjectId returns the project of --projectId, or else the project of the local config, which machine identities cannot use
+func getTagsProjectId(cmd *cobra.Command, token *models.TokenDetails) string {
+	projectId, err := cmd.Flags().GetString("projectId")
+	if err != nil {
+		util.HandleError(err, "Unable to parse flag")
+	}
+
+	if projectId != "" {
+		return projectId
+	}
+
+	if token.Type == util.UNIVERSAL_AUTH_TOKEN_IDENTIFIER {
+		util.PrintErrorMessageAndExit("When using machine identities, you must set the --projectId flag")
+	}
+
+	workspaceFile, err := util.GetWorkSpaceFromFile()
+	if err != nil {
+		util.HandleError(err, "Unable to get local project details")
+	}
+
+	return workspaceFile.WorkspaceId
+}
+
+func init() {
+	tagsCmd.AddCommand(tagsListCmd)
+
+	tagsCreateCmd.Flags().String("name", "", "the display name of the tag (default: the slug)")
+	tagsCreateCmd.Flags().String("color", DefaultTagColor, "the color of the tag in the dashboard, as a hex code")
+	tagsCmd.AddCommand(tagsCreateCmd)
+
+	tagsCmd.AddCommand(tagsDeleteCmd)
+
+	tagsCmd.PersistentFlags().String("token", "", "Use a machine identity access token instead of the logged in user")
+	tagsCmd.PersistentFlags().String("projectId", "", "manually set the project ID of the tags when using machine identity based auth")
+	rootCmd.AddCommand(tagsCmd)
+}
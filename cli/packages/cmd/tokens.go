@@ -13,10 +13,25 @@ import (
 	"github.com/Infisical/infisical-merge/packages/api"
 	"github.com/Infisical/infisical-merge/packages/crypto"
 	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
 	"github.com/go-resty/resty/v2"
 	"github.com/spf13/cobra"
 )
 
+// serviceTokenOutput is the JSON and YAML schema of a new service token
+type serviceTokenOutput struct {
+	Name         string                    `json:"name" yaml:"name"`
+	ProjectID    string                    `json:"projectId" yaml:"projectId"`
+	AccessTypes  []string                  `json:"accessTypes" yaml:"accessTypes"`
+	Scopes       []serviceTokenScopeOutput `json:"scopes" yaml:"scopes"`
+	ServiceToken string                    `json:"serviceToken" yaml:"serviceToken"`
+}
+
+type serviceTokenScopeOutput struct {
+	Environment string `json:"environment" yaml:"environment"`
+	SecretPath  string `json:"secretPath" yaml:"secretPath"`
+}
+
 var tokensCmd = &cobra.Command{
 	Use:                   "service-token",
 	Short:                 "Manage service tokens",
@@ -161,17 +176,28 @@ var tokensCreateCmd = &cobra.Command{
 		if tokenOnly {
 			fmt.Println(serviceToken)
 		} else {
+			output := serviceTokenOutput{
+				Name:         serviceTokenName,
+				ProjectID:    workspaceId,
+				AccessTypes:  accessLevels,
+				Scopes:       []serviceTokenScopeOutput{},
+				ServiceToken: serviceToken,
+			}
+
 			printablePermission := []string{}
 			for _, permission := range permissions {
+				output.Scopes = append(output.Scopes, serviceTokenScopeOutput{Environment: permission.Environment, SecretPath: permission.SecretPath})
 				printablePermission = append(printablePermission, fmt.Sprintf("([environment: %v] [path: %v])", permission.Environment, permission.SecretPath))
 			}
 
-			fmt.Printf("New service token created\n")
-			fmt.Printf("Name: %v\n", serviceTokenName)
-			fmt.Printf("Project ID: %v\n", workspaceId)
-			fmt.Printf("Access type: [%v]\n", strings.Join(accessLevels, ", "))
-			fmt.Printf("Permission(s): %v\n", strings.Join(printablePermission, ", "))
-			fmt.Printf("Service Token: %v\n", serviceToken)
+			visualize.Render(output, func() {
+				fmt.Printf("New service token created\n")
+				fmt.Printf("Name: %v\n", serviceTokenName)
+				fmt.Printf("Project ID: %v\n", workspaceId)
+				fmt.Printf("Access type: [%v]\n", strings.Join(accessLevels, ", "))
+				fmt.Printf("Permission(s): %v\n", strings.Join(printablePermission, ", "))
+				fmt.Printf("Service Token: %v\n", serviceToken)
+			})
 		}
 	},
 }
@@ -4,10 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strconv"
 
 	"github.com/Infisical/infisical-merge/packages/config"
 	"github.com/Infisical/infisical-merge/packages/models"
 	"github.com/Infisical/infisical-merge/packages/util"
+	"github.com/Infisical/infisical-merge/packages/visualize"
 	"github.com/manifoldco/promptui"
 	"github.com/posthog/posthog-go"
 	"github.com/spf13/cobra"
@@ -24,6 +26,45 @@ var userCmd = &cobra.Command{
 	},
 }
 
+var userGetCmd = &cobra.Command{
+	Use:                   "get",
+	Short:                 "Used to list the Infisical profiles you are logged in with",
+	DisableFlagsInUseLine: true,
+	Example:               "infisical user get --output=json",
+	Args:                  cobra.ExactArgs(0),
+	PreRun: func(cmd *cobra.Command, args []string) {
+		util.RequireLogin()
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		configFile, err := util.GetConfigFile()
+		if err != nil {
+			util.HandleError(err, "[infisical user get]: Unable to get config file")
+		}
+
+		output := []userProfileOutput{}
+		rows := [][]string{}
+		for _, loggedInUser := range configFile.LoggedInUsers {
+			active := loggedInUser.Email == configFile.LoggedInUserEmail
+			output = append(output, userProfileOutput{Email: loggedInUser.Email, Domain: loggedInUser.Domain, Active: active})
+			rows = append(rows, []string{loggedInUser.Email, loggedInUser.Domain, strconv.FormatBool(active)})
+		}
+
+		visualize.Render(output, func() {
+			visualize.GenericTable([]string{"EMAIL", "DOMAIN", "ACTIVE"}, rows)
+		})
+
+		Telemetry.CaptureEvent("cli-command:user get", posthog.NewProperties().Set("numberOfLoggedInProfiles", len(output)).Set("version", util.CLI_VERSION))
+	},
+}
+
+// userProfileOutput is the JSON and YAML schema of a logged in profile
+type userProfileOutput struct {
+	Email  string `json:"email" yaml:"email"`
+	Domain string `json:"domain" yaml:"domain"`
+	// whether the profile is the one used by the other commands
+	Active bool `json:"active" yaml:"active"`
+}
+
 var switchCmd = &cobra.Command{
 	Use:                   "switch",
 	Short:                 "Used to switch between Infisical profiles",
@@ -186,6 +227,7 @@ func init() {
 	updateCmd.AddCommand(domainCmd)
 	userCmd.AddCommand(updateCmd)
 	userCmd.AddCommand(switchCmd)
+	userCmd.AddCommand(userGetCmd)
 	rootCmd.AddCommand(userCmd)
 }
 
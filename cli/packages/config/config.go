@@ -3,3 +3,6 @@ package config
 var INFISICAL_URL string
 var INFISICAL_URL_MANUAL_OVERRIDE string
 var INFISICAL_LOGIN_URL string
+
+// OUTPUT_FORMAT is set by the global --output flag
+var OUTPUT_FORMAT string
@@ -107,10 +107,31 @@ type GetAllSecretsParameters struct {
 	ExpandSecretReferences   bool
 }
 
+type SecretKeyTransformOptions struct {
+	Include      []string          // glob patterns, only matching keys are kept
+	Exclude      []string          // glob patterns, matching keys are dropped even if they are included
+	StripPrefix  string            // removed from the start of every key that has it
+	Prefix       string            // added to the start of every key that is not explicitly mapped
+	KeyMappings  map[string]string // explicit renames from the stored key to the new key
+	SanitizeKeys bool              // rename keys that are not valid POSIX environment variable names to UPPER_SNAKE
+}
+
+type MergedSecretsResult struct {
+	Secrets     []SingleEnvironmentVariable
+	SourceByKey map[string]string // the source that supplied the final value of each key
+	ETag        string            // changes whenever a secret in any of the sources changes
+}
+
 type InjectableEnvironmentResult struct {
 	Variables    []string
+	Secrets      []SingleEnvironmentVariable
+	SourceByKey  map[string]string
 	ETag         string
 	SecretsCount int
+
+	InheritedVariables []string // variables inherited from the CLI's own environment, in KEY=VALUE form
+	OverriddenKeys     []string // inherited variables that are overridden by a secret
+	ReservedKeys       []string // secrets that are not injected because their names are reserved
 }
 
 type GetAllFoldersParameters struct {
@@ -151,6 +172,25 @@ type SecretSetOperation struct {
 	SecretKey       string
 	SecretValue     string
 	SecretOperation string
+	Error           error
+}
+
+type SetSecretsOptions struct {
+	DryRun bool
+	// Atomic rolls back the secrets that were written when any of the writes fails
+	Atomic      bool
+	Concurrency int
+	// Metadata is applied to every secret that is set
+	Metadata SecretMetadataChanges
+}
+
+// SecretMetadataChanges are the changes to the comment, tags and reminder of the secrets that are set. Nil fields are left unchanged
+type SecretMetadataChanges struct {
+	Comment            *string
+	AddTagIDs          []string
+	RemoveTagIDs       []string
+	ReminderNote       *string
+	ReminderRepeatDays *int
 }
 
 type BackupSecretKeyRing struct {
@@ -159,3 +199,63 @@ type BackupSecretKeyRing struct {
 	SecretPath  string `json:"secretPath"`
 	Secrets     []SingleEnvironmentVariable
 }
+
+type ProcfileEntry struct {
+	Name    string
+	Command string
+}
+
+type SecretsDiff struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+// SecretComparison is the state of a secret key between the two sides compared by `infisical secrets diff`
+type SecretComparison struct {
+	Key    string `json:"key" yaml:"key"`
+	Status string `json:"status" yaml:"status"`
+	// the values are only set when they are shown, either as is or as hashes
+	FromValue string `json:"fromValue,omitempty" yaml:"fromValue,omitempty"`
+	ToValue   string `json:"toValue,omitempty" yaml:"toValue,omitempty"`
+}
+
+// SecretCopyOperation is the planned action for a secret of `infisical secrets copy`. SecretPath is relative to the copied folders
+type SecretCopyOperation struct {
+	SecretPath string
+	Key        string
+	Value      string
+	Action     string
+}
+
+// SecretsBundle is the encrypted content written by `infisical export --encrypt-to` and read by `infisical import-bundle`
+type SecretsBundle struct {
+	Version     int       `json:"version"`
+	Project     string    `json:"project"`
+	Environment string    `json:"environment"`
+	Path        string    `json:"path"`
+	Sources     []string  `json:"sources,omitempty"`
+	ExportedAt  time.Time `json:"exportedAt"`
+	Format      string    `json:"format"`
+	Payload     string    `json:"payload"` // the secrets, rendered in Format
+}
+
+// SecretVersion is a past or current value of a secret, as listed by `infisical secrets history`
+type SecretVersion struct {
+	Version   int       `json:"version" yaml:"version"`
+	Value     string    `json:"value" yaml:"value"`
+	Comment   string    `json:"comment,omitempty" yaml:"comment,omitempty"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+	// the user or machine identity that wrote the version, empty when the audit logs do not have it
+	Actor string `json:"actor,omitempty" yaml:"actor,omitempty"`
+}
+
+// SnapshotChange is what restoring a snapshot would do to a secret or sub-folder of the folder, as shown by `infisical snapshots diff`
+type SnapshotChange struct {
+	Kind   string `json:"kind" yaml:"kind"`
+	Name   string `json:"name" yaml:"name"`
+	Action string `json:"action" yaml:"action"`
+	// the values are only set for secrets, and only when they are shown
+	CurrentValue  string `json:"currentValue,omitempty" yaml:"currentValue,omitempty"`
+	SnapshotValue string `json:"snapshotValue,omitempty" yaml:"snapshotValue,omitempty"`
+}
@@ -0,0 +1,118 @@
+package util
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/agessh"
+	"filippo.io/age/armor"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"golang.org/x/crypto/ssh"
+)
+
+const SECRETS_BUNDLE_VERSION = 1
+
+// ParseBundleRecipient parses an age recipient (age1...) or an SSH public key (ssh-ed25519 or ssh-rsa) that a bundle is encrypted to
+func ParseBundleRecipient(recipient string) (age.Recipient, error) {
+	recipient = strings.TrimSpace(recipient)
+
+	switch {
+	case strings.HasPrefix(recipient, "age1"):
+		return age.ParseX25519Recipient(recipient)
+	case strings.HasPrefix(recipient, "ssh-"):
+		return agessh.ParseRecipient(recipient)
+	default:
+		return nil, fmt.Errorf("unknown recipient type %q. Recipients must be age public keys (age1...) or SSH public keys (ssh-ed25519 or ssh-rsa)", recipient)
+	}
+}
+
+// ParseBundleIdentities parses the content of an age identity file or an SSH private key.
+// The passphrase function is only called for passphrase protected SSH keys, when the bundle is decrypted
+func ParseBundleIdentities(key []byte, passphrase func() ([]byte, error)) ([]age.Identity, error) {
+	if bytes.Contains(key, []byte("AGE-SECRET-KEY-")) {
+		return age.ParseIdentities(bytes.NewReader(key))
+	}
+
+	identity, err := agessh.ParseIdentity(key)
+	if err == nil {
+		return []age.Identity{identity}, nil
+	}
+
+	var passphraseMissing *ssh.PassphraseMissingError
+	if !errors.As(err, &passphraseMissing) {
+		return nil, fmt.Errorf("the identity is neither an age identity file nor an SSH private key [err=%v]", err)
+	}
+	if passphraseMissing.PublicKey == nil {
+		return nil, fmt.Errorf("the SSH private key is passphrase protected and has no embedded public key")
+	}
+
+	encryptedIdentity, err := agessh.NewEncryptedSSHIdentity(passphraseMissing.PublicKey, key, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return []age.Identity{encryptedIdentity}, nil
+}
+
+// EncryptSecretsBundle encrypts the bundle to every recipient. The result is ASCII armored so it can be printed or copied as text
+func EncryptSecretsBundle(bundle models.SecretsBundle, recipients []age.Recipient) ([]byte, error) {
+	bundle.Version = SECRETS_BUNDLE_VERSION
+
+	content, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode bundle [err=%v]", err)
+	}
+
+	var encrypted bytes.Buffer
+	armorWriter := armor.NewWriter(&encrypted)
+	ageWriter, err := age.Encrypt(armorWriter, recipients...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encrypt bundle [err=%v]", err)
+	}
+
+	if _, err := ageWriter.Write(content); err != nil {
+		return nil, fmt.Errorf("unable to encrypt bundle [err=%v]", err)
+	}
+	if err := ageWriter.Close(); err != nil {
+		return nil, fmt.Errorf("unable to encrypt bundle [err=%v]", err)
+	}
+	if err := armorWriter.Close(); err != nil {
+		return nil, fmt.Errorf("unable to encrypt bundle [err=%v]", err)
+	}
+
+	encrypted.WriteString("\n")
+	return encrypted.Bytes(), nil
+}
+
+// DecryptSecretsBundle decrypts an armored or binary bundle with any of the identities
+func DecryptSecretsBundle(encrypted []byte, identities []age.Identity) (models.SecretsBundle, error) {
+	var reader io.Reader = bytes.NewReader(encrypted)
+	if bytes.HasPrefix(bytes.TrimSpace(encrypted), []byte(armor.Header)) {
+		reader = armor.NewReader(bytes.NewReader(bytes.TrimSpace(encrypted)))
+	}
+
+	decryptedReader, err := age.Decrypt(reader, identities...)
+	if err != nil {
+		return models.SecretsBundle{}, fmt.Errorf("unable to decrypt bundle [err=%v]", err)
+	}
+
+	content, err := io.ReadAll(decryptedReader)
+	if err != nil {
+		return models.SecretsBundle{}, fmt.Errorf("unable to decrypt bundle [err=%v]", err)
+	}
+
+	var bundle models.SecretsBundle
+	if err := json.Unmarshal(content, &bundle); err != nil {
+		return models.SecretsBundle{}, fmt.Errorf("unable to read bundle [err=%v]", err)
+	}
+
+	if bundle.Version != SECRETS_BUNDLE_VERSION {
+		return models.SecretsBundle{}, fmt.Errorf("unsupported bundle version %d, please update the Infisical CLI", bundle.Version)
+	}
+
+	return bundle, nil
+}
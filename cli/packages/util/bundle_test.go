@@ -0,0 +1,82 @@
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSecretsBundleRoundTrip(t *testing.T) {
+	bundle := models.SecretsBundle{
+		Project:     "project-id",
+		Environment: "prod",
+		Path:        "/api",
+		ExportedAt:  time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Format:      "dotenv",
+		Payload:     "API_KEY='secret'\n",
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+
+	recipient, err := ParseBundleRecipient(identity.Recipient().String())
+	assert.NoError(t, err)
+
+	encrypted, err := EncryptSecretsBundle(bundle, []age.Recipient{recipient})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encrypted), "secret")
+
+	identities, err := ParseBundleIdentities([]byte("# public key: "+identity.Recipient().String()+"\n"+identity.String()+"\n"), nil)
+	assert.NoError(t, err)
+
+	decrypted, err := DecryptSecretsBundle(encrypted, identities)
+	assert.NoError(t, err)
+	bundle.Version = SECRETS_BUNDLE_VERSION
+	assert.Equal(t, bundle, decrypted)
+
+	otherIdentity, err := age.GenerateX25519Identity()
+	assert.NoError(t, err)
+	_, err = DecryptSecretsBundle(encrypted, []age.Identity{otherIdentity})
+	assert.ErrorContains(t, err, "unable to decrypt bundle")
+}
+
+func TestSecretsBundleWithPassphraseProtectedSSHKey(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	sshPublicKey, err := ssh.NewPublicKey(publicKey)
+	assert.NoError(t, err)
+
+	privateKeyBlock, err := ssh.MarshalPrivateKeyWithPassphrase(privateKey, "", []byte("passphrase"))
+	assert.NoError(t, err)
+
+	recipient, err := ParseBundleRecipient(string(ssh.MarshalAuthorizedKey(sshPublicKey)))
+	assert.NoError(t, err)
+
+	encrypted, err := EncryptSecretsBundle(models.SecretsBundle{Format: "json", Payload: "[]"}, []age.Recipient{recipient})
+	assert.NoError(t, err)
+
+	prompted := false
+	identities, err := ParseBundleIdentities(pem.EncodeToMemory(privateKeyBlock), func() ([]byte, error) {
+		prompted = true
+		return []byte("passphrase"), nil
+	})
+	assert.NoError(t, err)
+
+	decrypted, err := DecryptSecretsBundle(encrypted, identities)
+	assert.NoError(t, err)
+	assert.True(t, prompted)
+	assert.Equal(t, "[]", decrypted.Payload)
+}
+
+func TestParseBundleRecipientInvalid(t *testing.T) {
+	_, err := ParseBundleRecipient("gpg:ABCDEF")
+	assert.ErrorContains(t, err, "unknown recipient type")
+}
@@ -0,0 +1,38 @@
+package util
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	exitCleanupMutex sync.Mutex
+	exitCleanupFuncs []func()
+)
+
+// RegisterExitCleanup registers a function that runs before the CLI exits through Exit or one of the error helpers.
+// Cleanups run in reverse order of registration and only once
+func RegisterExitCleanup(cleanup func()) {
+	exitCleanupMutex.Lock()
+	defer exitCleanupMutex.Unlock()
+
+	exitCleanupFuncs = append(exitCleanupFuncs, cleanup)
+}
+
+// RunExitCleanups runs all registered cleanups
+func RunExitCleanups() {
+	exitCleanupMutex.Lock()
+	cleanups := exitCleanupFuncs
+	exitCleanupFuncs = nil
+	exitCleanupMutex.Unlock()
+
+	for i := len(cleanups) - 1; i >= 0; i-- {
+		cleanups[i]()
+	}
+}
+
+// Exit runs the registered cleanups and then exits with the given status code
+func Exit(code int) {
+	RunExitCleanups()
+	os.Exit(code)
+}
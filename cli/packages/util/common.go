@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 
 	"github.com/Infisical/infisical-merge/packages/config"
 )
@@ -23,6 +24,36 @@ func WriteToFile(fileName string, dataToWrite []byte, filePerm os.FileMode) erro
 	return nil
 }
 
+// WriteFileAtomically writes the data to a temporary file next to the destination and renames it into place,
+// so readers never observe a partially written file
+func WriteFileAtomically(fileName string, dataToWrite []byte, filePerm os.FileMode) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary file [err=%v]", err)
+	}
+	tempFileName := tempFile.Name()
+	defer os.Remove(tempFileName)
+
+	if _, err := tempFile.Write(dataToWrite); err != nil {
+		tempFile.Close()
+		return fmt.Errorf("unable to write to temporary file [err=%v]", err)
+	}
+
+	if err := tempFile.Close(); err != nil {
+		return fmt.Errorf("unable to close temporary file [err=%v]", err)
+	}
+
+	if err := os.Chmod(tempFileName, filePerm); err != nil {
+		return fmt.Errorf("unable to set file permissions [err=%v]", err)
+	}
+
+	if err := os.Rename(tempFileName, fileName); err != nil {
+		return fmt.Errorf("unable to move file into place [err=%v]", err)
+	}
+
+	return nil
+}
+
 func ValidateInfisicalAPIConnection() (ok bool) {
 	_, err := http.Get(fmt.Sprintf("%v/status", config.INFISICAL_URL))
 	return err == nil
@@ -29,12 +29,44 @@ const (
 	// Generic env variable used for auth methods that require a machine identity ID
 	INFISICAL_MACHINE_IDENTITY_ID_NAME = "INFISICAL_MACHINE_IDENTITY_ID"
 
+	// Passed to processes started by `infisical run` so they know where to re-read secrets from
+	INFISICAL_SECRETS_FILE_ENV_NAME = "INFISICAL_SECRETS_FILE"
+
+	// Passed to processes started by `infisical run --mount-dir` so they know where their secrets were written to
+	INFISICAL_SECRETS_DIR_ENV_NAME = "INFISICAL_SECRETS_DIR"
+
 	SECRET_TYPE_PERSONAL      = "personal"
 	SECRET_TYPE_SHARED        = "shared"
 	KEYRING_SERVICE_NAME      = "infisical"
 	PERSONAL_SECRET_TYPE_NAME = "personal"
 	SHARED_SECRET_TYPE_NAME   = "shared"
 
+	// The status of a secret in `infisical secrets diff`, from the point of view of the --to side
+	SECRET_COMPARISON_MISSING   = "missing"
+	SECRET_COMPARISON_EXTRA     = "extra"
+	SECRET_COMPARISON_DIFFERENT = "different"
+	SECRET_COMPARISON_IDENTICAL = "identical"
+
+	// What to do in `infisical secrets copy` with secrets that already exist in the target with a different value
+	SECRET_CONFLICT_POLICY_SKIP      = "skip"
+	SECRET_CONFLICT_POLICY_OVERWRITE = "overwrite"
+	SECRET_CONFLICT_POLICY_FAIL      = "fail"
+
+	// The planned action for a secret in `infisical secrets copy`
+	SECRET_COPY_CREATE    = "create"
+	SECRET_COPY_OVERWRITE = "overwrite"
+	SECRET_COPY_SKIP      = "skip"
+	SECRET_COPY_UNCHANGED = "unchanged"
+	SECRET_COPY_CONFLICT  = "conflict"
+
+	// What restoring a snapshot in `infisical snapshots restore` would do to a secret or sub-folder of the folder
+	SNAPSHOT_CHANGE_CREATE = "create"
+	SNAPSHOT_CHANGE_UPDATE = "update"
+	SNAPSHOT_CHANGE_DELETE = "delete"
+
+	SNAPSHOT_CHANGE_KIND_SECRET = "secret"
+	SNAPSHOT_CHANGE_KIND_FOLDER = "folder"
+
 	SERVICE_TOKEN_IDENTIFIER        = "service-token"
 	UNIVERSAL_AUTH_TOKEN_IDENTIFIER = "universal-auth-token"
 
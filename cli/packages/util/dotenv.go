@@ -0,0 +1,172 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+)
+
+// QuoteDotEnvValue quotes a value so that both shells and dotenv parsers read it back unchanged.
+// Values are single quoted, which keeps every character literal, including line breaks of multi-line values such as PEM keys.
+// Values that contain a single quote are double quoted instead, with \, ", $ and ` escaped
+func QuoteDotEnvValue(value string) string {
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+
+	return `"` + dotEnvDoubleQuoteEscaper.Replace(value) + `"`
+}
+
+var dotEnvDoubleQuoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "`", "\\`")
+
+// ParseDotEnv parses the content of a dotenv file into secrets, in the order they appear. When a key appears more than once, the last value wins.
+// Lines may start with export, values may be unquoted, single quoted (literal) or double quoted (with backslash escapes), and quoted values may span multiple lines.
+// Variable references such as ${OTHER} are not expanded
+func ParseDotEnv(content string) ([]models.SingleEnvironmentVariable, error) {
+	secrets := []models.SingleEnvironmentVariable{}
+	indexByKey := map[string]int{}
+
+	// line breaks are not normalised, so that carriage returns inside quoted values are kept. Whitespace around keys and unquoted values, including \r, is trimmed
+	parser := dotEnvParser{content: content, line: 1}
+	for {
+		key, value, ok, err := parser.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+
+		if index, exists := indexByKey[key]; exists {
+			secrets[index].Value = value
+			continue
+		}
+		indexByKey[key] = len(secrets)
+		secrets = append(secrets, models.SingleEnvironmentVariable{Key: key, Value: value, Type: SECRET_TYPE_SHARED})
+	}
+
+	return secrets, nil
+}
+
+type dotEnvParser struct {
+	content string
+	pos     int
+	line    int
+}
+
+// next parses the next KEY=value pair, skipping blank lines and comments. ok is false at the end of the content
+func (p *dotEnvParser) next() (key string, value string, ok bool, err error) {
+	for p.pos < len(p.content) {
+		line := p.currentLine()
+		trimmedLine := strings.TrimSpace(line)
+
+		if trimmedLine == "" || strings.HasPrefix(trimmedLine, "#") {
+			p.skipLine()
+			continue
+		}
+
+		startLine := p.line
+		keyPart, valuePart, found := strings.Cut(trimmedLine, "=")
+		if !found {
+			return "", "", false, fmt.Errorf("invalid dotenv line %d: expected KEY=value", startLine)
+		}
+
+		key = strings.TrimSpace(keyPart)
+		if exportedKey, isExported := strings.CutPrefix(key, "export "); isExported {
+			key = strings.TrimSpace(exportedKey)
+		}
+		if key == "" || strings.ContainsAny(key, " \t\"'") {
+			return "", "", false, fmt.Errorf("invalid dotenv line %d: invalid key %q", startLine, key)
+		}
+
+		// move to the start of the value, which may continue over the following lines when it is quoted
+		p.pos += strings.Index(line, "=") + 1
+		for p.pos < len(p.content) && (p.content[p.pos] == ' ' || p.content[p.pos] == '\t') {
+			p.pos++
+		}
+
+		if strings.HasPrefix(strings.TrimLeft(valuePart, " \t"), "'") || strings.HasPrefix(strings.TrimLeft(valuePart, " \t"), `"`) {
+			value, err = p.parseQuotedValue(startLine, key)
+			if err != nil {
+				return "", "", false, err
+			}
+			return key, value, true, nil
+		}
+
+		value = p.currentLine()
+		if commentIndex := strings.Index(value, " #"); commentIndex != -1 {
+			value = value[:commentIndex]
+		}
+		p.skipLine()
+		return key, strings.TrimSpace(value), true, nil
+	}
+
+	return "", "", false, nil
+}
+
+func (p *dotEnvParser) parseQuotedValue(startLine int, key string) (string, error) {
+	quote := p.content[p.pos]
+	p.pos++
+
+	var value strings.Builder
+	for p.pos < len(p.content) {
+		c := p.content[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			// only whitespace and a comment may follow the closing quote
+			rest := strings.TrimSpace(p.currentLine())
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("invalid dotenv line %d: unexpected characters after the value of %s", p.line, key)
+			}
+			p.skipLine()
+			return value.String(), nil
+		case c == '\\' && quote == '"' && p.pos+1 < len(p.content):
+			p.pos++
+			switch escaped := p.content[p.pos]; escaped {
+			case 'n':
+				value.WriteByte('\n')
+			case 'r':
+				value.WriteByte('\r')
+			case 't':
+				value.WriteByte('\t')
+			case '\\', '"', '$', '`':
+				value.WriteByte(escaped)
+			case '\n':
+				// a backslash at the end of a line continues the value on the next line
+				p.line++
+			default:
+				value.WriteByte('\\')
+				value.WriteByte(escaped)
+			}
+			p.pos++
+		default:
+			if c == '\n' {
+				p.line++
+			}
+			value.WriteByte(c)
+			p.pos++
+		}
+	}
+
+	return "", fmt.Errorf("invalid dotenv line %d: the value of %s is missing its closing quote", startLine, key)
+}
+
+// currentLine returns the rest of the current line, without the line break
+func (p *dotEnvParser) currentLine() string {
+	line := p.content[p.pos:]
+	if end := strings.IndexByte(line, '\n'); end != -1 {
+		return line[:end]
+	}
+	return line
+}
+
+func (p *dotEnvParser) skipLine() {
+	if end := strings.IndexByte(p.content[p.pos:], '\n'); end != -1 {
+		p.pos += end + 1
+		p.line++
+		return
+	}
+	p.pos = len(p.content)
+}
@@ -2,10 +2,15 @@ package util
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
 	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 )
 
@@ -27,6 +32,34 @@ func IsProcessRunning(p *os.Process) bool {
 	return err == nil
 }
 
+// ParseSignal converts a signal name such as SIGTERM, TERM or sigterm, or a signal number such as 15, into a signal that can be sent to a process
+func ParseSignal(name string) (syscall.Signal, error) {
+	if number, err := strconv.Atoi(strings.TrimSpace(name)); err == nil {
+		for _, sig := range signalsByName {
+			if int(sig) == number {
+				return sig, nil
+			}
+		}
+	}
+
+	normalizedName := strings.ToUpper(strings.TrimSpace(name))
+	if !strings.HasPrefix(normalizedName, "SIG") {
+		normalizedName = "SIG" + normalizedName
+	}
+
+	if sig, ok := signalsByName[normalizedName]; ok {
+		return sig, nil
+	}
+
+	supportedSignals := make([]string, 0, len(signalsByName))
+	for signalName := range signalsByName {
+		supportedSignals = append(supportedSignals, signalName)
+	}
+	sort.Strings(supportedSignals)
+
+	return 0, fmt.Errorf("unsupported signal %q. Supported signals are [%s]", name, strings.Join(supportedSignals, ", "))
+}
+
 // For "infisical run -- COMMAND"
 func RunCommandFromArgs(args []string, env []string, waitForExit bool) (*exec.Cmd, error) {
 	cmd := exec.Command(args[0], args[1:]...)
@@ -40,37 +73,113 @@ func RunCommandFromArgs(args []string, env []string, waitForExit bool) (*exec.Cm
 	return cmd, err
 }
 
+// signalForwarders holds the function that stops forwarding signals to each command started without waiting for its exit
+var signalForwarders sync.Map
+
 func execCommand(cmd *exec.Cmd, waitForExit bool) error {
 	sigChannel := make(chan os.Signal, 1)
 	signal.Notify(sigChannel)
 
 	if err := cmd.Start(); err != nil {
+		signal.Stop(sigChannel)
 		return err
 	}
 
+	done := make(chan struct{})
 	go func() {
 		for {
-			sig := <-sigChannel
-			_ = cmd.Process.Signal(sig) // process all sigs
+			select {
+			case sig := <-sigChannel:
+				_ = cmd.Process.Signal(sig) // process all sigs
+			case <-done:
+				return
+			}
 		}
 	}()
 
+	var stopOnce sync.Once
+	stopForwarding := func() {
+		stopOnce.Do(func() {
+			signal.Stop(sigChannel)
+			close(done)
+		})
+	}
+
 	if !waitForExit {
+		signalForwarders.Store(cmd, stopForwarding)
 		return nil
 	}
 
-	if err := cmd.Wait(); err != nil {
-		_ = cmd.Process.Signal(os.Kill)
-		return fmt.Errorf("failed to wait for command termination: %v", err)
+	err := cmd.Wait()
+	stopForwarding()
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			_ = cmd.Process.Signal(os.Kill)
+			return fmt.Errorf("failed to wait for command termination: %v", err)
+		}
 	}
 
-	waitStatus := cmd.ProcessState.Sys().(syscall.WaitStatus)
-	os.Exit(waitStatus.ExitStatus())
+	Exit(GetExitCode(cmd.ProcessState))
 	return nil
 }
 
+// WaitForCommand waits for a command that was started without waiting for its exit, then stops forwarding signals to it
+func WaitForCommand(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	if stopForwarding, ok := signalForwarders.LoadAndDelete(cmd); ok {
+		stopForwarding.(func())()
+	}
+	return err
+}
+
+// GetExitCode returns the exit code of a process the way shells report it:
+// its exit status, or 128 + the signal number when it was killed by a signal
+func GetExitCode(state *os.ProcessState) int {
+	if waitStatus, ok := state.Sys().(syscall.WaitStatus); ok {
+		return exitCodeFromWaitStatus(waitStatus)
+	}
+	return state.ExitCode()
+}
+
+func exitCodeFromWaitStatus(waitStatus syscall.WaitStatus) int {
+	if waitStatus.Signaled() {
+		return 128 + int(waitStatus.Signal())
+	}
+	return waitStatus.ExitStatus()
+}
+
 // For "infisical run --command=COMMAND"
 func RunCommandFromString(command string, env []string, waitForExit bool) (*exec.Cmd, error) {
+	cmd := newShellCommand(command)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err := execCommand(cmd, waitForExit)
+	return cmd, err
+}
+
+// RunCommandWithIO starts the command like RunCommand but connects it to the given streams instead of the CLI's own, e.g. to mask or prefix its output.
+// The command does not read any input when stdin is nil
+func RunCommandWithIO(singleCommand string, args []string, env []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) (*exec.Cmd, error) {
+	var cmd *exec.Cmd
+	if singleCommand != "" {
+		cmd = newShellCommand(singleCommand)
+	} else {
+		cmd = exec.Command(args[0], args[1:]...)
+	}
+
+	cmd.Env = env
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := execCommand(cmd, false)
+	return cmd, err
+}
+
+func newShellCommand(command string) *exec.Cmd {
 	shell := [2]string{"sh", "-c"}
 	if runtime.GOOS == "windows" {
 		shell = [2]string{"cmd", "/C"}
@@ -81,12 +190,5 @@ func RunCommandFromString(command string, env []string, waitForExit bool) (*exec
 		}
 	}
 
-	cmd := exec.Command(shell[0], shell[1], command) // #nosec G204 nosemgrep: semgrep_configs.prohibit-exec-command
-	cmd.Env = env
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	err := execCommand(cmd, waitForExit)
-	return cmd, err
+	return exec.Command(shell[0], shell[1], command) // #nosec G204 nosemgrep: semgrep_configs.prohibit-exec-command
 }
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"io"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSignal(t *testing.T) {
+	for _, name := range []string{"SIGTERM", "TERM", "sigterm", " term ", "15"} {
+		sig, err := ParseSignal(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, syscall.SIGTERM, sig, name)
+	}
+
+	sig, err := ParseSignal("HUP")
+	assert.NoError(t, err)
+	assert.Equal(t, syscall.SIGHUP, sig)
+
+	for _, name := range []string{"", "SIGNOPE", "-1", "999"} {
+		_, err := ParseSignal(name)
+		assert.ErrorContains(t, err, "unsupported signal", name)
+	}
+}
+
+func TestWaitForCommandStopsSignalForwarding(t *testing.T) {
+	// the test binary itself is the only command known to exist on every platform
+	cmd, err := RunCommandWithIO("", []string{os.Args[0], "-test.run=^$"}, os.Environ(), nil, io.Discard, io.Discard)
+	assert.NoError(t, err)
+
+	_, forwarding := signalForwarders.Load(cmd)
+	assert.True(t, forwarding)
+
+	assert.NoError(t, WaitForCommand(cmd))
+
+	_, forwarding = signalForwarders.Load(cmd)
+	assert.False(t, forwarding)
+}
@@ -298,6 +298,61 @@ func GetCmdFlagOrEnv(cmd *cobra.Command, flag, envName string) (string, error) {
 	return value, nil
 }
 
+// GetSecretKeyTransformOptions reads the key filtering and renaming flags shared by commands that output secrets
+func GetSecretKeyTransformOptions(cmd *cobra.Command) (models.SecretKeyTransformOptions, error) {
+	include, err := cmd.Flags().GetStringSlice("include")
+	if err != nil {
+		return models.SecretKeyTransformOptions{}, err
+	}
+
+	exclude, err := cmd.Flags().GetStringSlice("exclude")
+	if err != nil {
+		return models.SecretKeyTransformOptions{}, err
+	}
+
+	stripPrefix, err := cmd.Flags().GetString("strip-prefix")
+	if err != nil {
+		return models.SecretKeyTransformOptions{}, err
+	}
+
+	prefix, err := cmd.Flags().GetString("prefix")
+	if err != nil {
+		return models.SecretKeyTransformOptions{}, err
+	}
+
+	mappings, err := cmd.Flags().GetStringSlice("map")
+	if err != nil {
+		return models.SecretKeyTransformOptions{}, err
+	}
+
+	sanitizeKeys, err := cmd.Flags().GetBool("sanitize-keys")
+	if err != nil {
+		return models.SecretKeyTransformOptions{}, err
+	}
+
+	for _, patterns := range [][]string{include, exclude} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return models.SecretKeyTransformOptions{}, fmt.Errorf("invalid key pattern %q [err=%v]", pattern, err)
+			}
+		}
+	}
+
+	keyMappings, err := ParseSecretKeyMappings(mappings)
+	if err != nil {
+		return models.SecretKeyTransformOptions{}, err
+	}
+
+	return models.SecretKeyTransformOptions{
+		Include:      include,
+		Exclude:      exclude,
+		StripPrefix:  stripPrefix,
+		Prefix:       prefix,
+		KeyMappings:  keyMappings,
+		SanitizeKeys: sanitizeKeys,
+	}, nil
+}
+
 func GenerateRandomString(length int) string {
 	b := make([]byte, length)
 	for i := range b {
@@ -0,0 +1,107 @@
+//go:build !windows
+
+package util
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/mattn/go-isatty"
+	"github.com/rs/zerolog/log"
+)
+
+// signals an init process forwards to the process group of its command. Everything else, such as SIGCHLD or SIGURG, is handled by the CLI itself
+var initForwardedSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP}
+
+// RunCommandAsInit runs the command the way the init process of a container would. The command is started in its own process group,
+// termination signals are forwarded to the whole group and every orphaned child that is re-parented to the CLI is reaped.
+// If the group has not exited stopTimeout after a termination signal, it is killed. It returns the exit code of the command
+func RunCommandAsInit(cmd *exec.Cmd, stopTimeout time.Duration) (int, error) {
+	setChildSubreaper()
+
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+
+	// an interactive command needs its process group in the foreground to read from the terminal
+	if cmd.Stdin == os.Stdin && isatty.IsTerminal(os.Stdin.Fd()) {
+		cmd.SysProcAttr.Foreground = true
+		cmd.SysProcAttr.Ctty = int(os.Stdin.Fd())
+	}
+
+	childExits := make(chan os.Signal, 1)
+	signal.Notify(childExits, syscall.SIGCHLD)
+	defer signal.Stop(childExits)
+
+	forwardedSignals := make(chan os.Signal, 1)
+	signal.Notify(forwardedSignals, initForwardedSignals...)
+	defer signal.Stop(forwardedSignals)
+
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go forwardSignalsToProcessGroup(cmd.Process.Pid, forwardedSignals, stopTimeout, done)
+
+	for {
+		if status, exited := reapChildren(cmd.Process.Pid); exited {
+			// the command has already been reaped, waiting only flushes its output
+			_ = cmd.Wait()
+			return exitCodeFromWaitStatus(status), nil
+		}
+		<-childExits
+	}
+}
+
+// reapChildren waits for every child that has exited, and reports the exit status of the command if it is one of them
+func reapChildren(commandPid int) (syscall.WaitStatus, bool) {
+	var commandStatus syscall.WaitStatus
+	commandExited := false
+
+	for {
+		var status syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &status, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || pid <= 0 {
+			return commandStatus, commandExited
+		}
+
+		if pid == commandPid {
+			commandStatus = status
+			commandExited = true
+		} else {
+			log.Debug().Msgf("reaped orphaned process %d", pid)
+		}
+	}
+}
+
+func forwardSignalsToProcessGroup(pgid int, signals chan os.Signal, stopTimeout time.Duration, done chan struct{}) {
+	var killTimer <-chan time.Time
+
+	for {
+		select {
+		case <-done:
+			return
+		case sig := <-signals:
+			log.Debug().Msgf("forwarding %v to process group %d", sig, pgid)
+			if err := syscall.Kill(-pgid, sig.(syscall.Signal)); err != nil {
+				log.Debug().Err(err).Msgf("unable to forward %v to process group %d", sig, pgid)
+			}
+
+			if sig != syscall.SIGHUP && killTimer == nil {
+				killTimer = time.After(stopTimeout)
+			}
+		case <-killTimer:
+			log.Warn().Msgf("Process has not exited %v after the stop signal, sending SIGKILL to its process group", stopTimeout)
+			_ = syscall.Kill(-pgid, syscall.SIGKILL)
+		}
+	}
+}
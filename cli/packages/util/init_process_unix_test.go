@@ -0,0 +1,31 @@
+//go:build !windows
+
+package util
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommandAsInitExitCode(t *testing.T) {
+	exitCode, err := RunCommandAsInit(exec.Command("sh", "-c", "exit 7"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, exitCode)
+
+	// a command killed by a signal exits with 128 + the signal number, like in a shell
+	exitCode, err = RunCommandAsInit(exec.Command("sh", "-c", "kill -TERM $$"), time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 143, exitCode)
+}
+
+func TestRunCommandAsInitReapsOrphans(t *testing.T) {
+	// the background sleep is orphaned when the subshell exits. A zombie would still accept signals, a reaped process does not
+	cmd := exec.Command("sh", "-c", "orphan=$( (sleep 0.1 >/dev/null & echo $!) ); sleep 0.5; if kill -0 $orphan 2>/dev/null; then exit 1; fi")
+
+	exitCode, err := RunCommandAsInit(cmd, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, exitCode)
+}
@@ -0,0 +1,14 @@
+//go:build windows
+
+package util
+
+import (
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// RunCommandAsInit is not supported on Windows, which has neither process groups that can be signaled nor zombie processes
+func RunCommandAsInit(cmd *exec.Cmd, stopTimeout time.Duration) (int, error) {
+	return 0, fmt.Errorf("the --init flag is not supported on Windows")
+}
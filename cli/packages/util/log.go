@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/Infisical/infisical-merge/packages/visualize"
 	"github.com/fatih/color"
 )
 
@@ -23,7 +24,7 @@ func PrintErrorAndExit(exitCode int, err error, messages ...string) {
 	supportMsg := fmt.Sprintf("\n\nIf this issue continues, get support at https://infisical.com/slack")
 	fmt.Fprintln(os.Stderr, supportMsg)
 
-	os.Exit(exitCode)
+	Exit(exitCode)
 }
 
 func PrintWarning(message string) {
@@ -31,17 +32,27 @@ func PrintWarning(message string) {
 }
 
 func PrintSuccessMessage(message string) {
+	// keep stdout to the rendered data when it is read by scripts
+	if visualize.IsStructuredOutput() {
+		color.New(color.FgGreen).Fprintln(os.Stderr, message)
+		return
+	}
+
 	color.New(color.FgGreen).Println(message)
 }
 
 func PrintErrorMessageAndExit(messages ...string) {
+	PrintErrorMessageAndExitWithCode(1, messages...)
+}
+
+func PrintErrorMessageAndExitWithCode(exitCode int, messages ...string) {
 	if len(messages) > 0 {
 		for _, message := range messages {
 			fmt.Fprintln(os.Stderr, message)
 		}
 	}
 
-	os.Exit(1)
+	Exit(exitCode)
 }
 
 func printError(e error) {
@@ -0,0 +1,242 @@
+package util
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+)
+
+const (
+	SECRET_MASK = "***"
+
+	// shorter values would mask too much unrelated output
+	MINIMUM_MASKED_SECRET_LENGTH = 4
+
+	// output that could be the start of a secret is written anyway once nothing more was written for this long,
+	// so prompts without a trailing newline still show
+	MASKING_WRITER_FLUSH_DELAY = 200 * time.Millisecond
+)
+
+// SecretMasker holds the values that are masked by its writers. The raw, base64 and URL-encoded forms of every value are masked
+type SecretMasker struct {
+	mutex           sync.RWMutex
+	patterns        [][]byte
+	maskedValues    map[string]bool
+	longestPattern  int
+	warnedShortKeys map[string]bool
+}
+
+func NewSecretMasker() *SecretMasker {
+	return &SecretMasker{
+		maskedValues:    map[string]bool{},
+		warnedShortKeys: map[string]bool{},
+	}
+}
+
+// AddSecrets adds the values of the given secrets to the values that are masked. Values that were added before stay masked
+func (m *SecretMasker) AddSecrets(secrets []models.SingleEnvironmentVariable) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, secret := range secrets {
+		if strings.TrimSpace(secret.Value) == "" {
+			continue
+		}
+
+		if len(secret.Value) < MINIMUM_MASKED_SECRET_LENGTH {
+			if !m.warnedShortKeys[secret.Key] {
+				PrintWarning(fmt.Sprintf("Infisical secret named [%v] is shorter than %d characters and will not be masked in the output", secret.Key, MINIMUM_MASKED_SECRET_LENGTH))
+				m.warnedShortKeys[secret.Key] = true
+			}
+			continue
+		}
+
+		for _, pattern := range getSecretValueEncodings(secret.Value) {
+			if m.maskedValues[pattern] {
+				continue
+			}
+			m.maskedValues[pattern] = true
+			m.patterns = append(m.patterns, []byte(pattern))
+		}
+	}
+
+	// longest first, so the longest pattern wins when several match at the same position
+	sort.SliceStable(m.patterns, func(i, j int) bool {
+		return len(m.patterns[i]) > len(m.patterns[j])
+	})
+
+	if len(m.patterns) > 0 {
+		m.longestPattern = len(m.patterns[0])
+	}
+}
+
+func getSecretValueEncodings(value string) []string {
+	return []string{
+		value,
+		base64.StdEncoding.EncodeToString([]byte(value)),
+		base64.URLEncoding.EncodeToString([]byte(value)),
+		base64.RawStdEncoding.EncodeToString([]byte(value)),
+		base64.RawURLEncoding.EncodeToString([]byte(value)),
+		url.QueryEscape(value),
+		url.PathEscape(value),
+	}
+}
+
+// NewWriter returns a writer that masks secret values before writing to out
+func (m *SecretMasker) NewWriter(out io.Writer) *MaskingWriter {
+	return &MaskingWriter{masker: m, out: out}
+}
+
+// mask replaces every secret in data. Unless final is set, the end of data that could still turn into a secret with the next write is held back and returned separately
+func (m *SecretMasker) mask(data []byte, final bool) ([]byte, []byte) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	output := bytes.Buffer{}
+	for {
+		index, length := m.findFirstMatch(data)
+		if index < 0 {
+			break
+		}
+
+		// a longer secret could start with this one, so wait for more data before deciding
+		if !final && m.isPartialMatch(data[index:]) {
+			output.Write(data[:index])
+			return output.Bytes(), data[index:]
+		}
+
+		output.Write(data[:index])
+		output.WriteString(SECRET_MASK)
+		data = data[index+length:]
+	}
+
+	if final {
+		output.Write(data)
+		return output.Bytes(), nil
+	}
+
+	heldBack := m.getPartialMatchSuffixLength(data)
+	output.Write(data[:len(data)-heldBack])
+	return output.Bytes(), data[len(data)-heldBack:]
+}
+
+// findFirstMatch returns the position and length of the leftmost, longest secret in data, or -1 if there is none
+func (m *SecretMasker) findFirstMatch(data []byte) (int, int) {
+	firstIndex, firstLength := -1, 0
+	for _, pattern := range m.patterns {
+		index := bytes.Index(data, pattern)
+		if index >= 0 && (firstIndex < 0 || index < firstIndex) {
+			firstIndex, firstLength = index, len(pattern)
+		}
+	}
+
+	return firstIndex, firstLength
+}
+
+// isPartialMatch reports whether data is the start of a secret that is longer than data
+func (m *SecretMasker) isPartialMatch(data []byte) bool {
+	for _, pattern := range m.patterns {
+		if len(pattern) > len(data) && bytes.HasPrefix(pattern, data) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getPartialMatchSuffixLength returns the length of the longest end of data that is the start of a secret
+func (m *SecretMasker) getPartialMatchSuffixLength(data []byte) int {
+	longestSuffix := m.longestPattern - 1
+	if longestSuffix > len(data) {
+		longestSuffix = len(data)
+	}
+
+	for length := longestSuffix; length > 0; length-- {
+		if m.isPartialMatch(data[len(data)-length:]) {
+			return length
+		}
+	}
+
+	return 0
+}
+
+// MaskingWriter masks secret values in a stream, including values that are split across writes
+type MaskingWriter struct {
+	masker  *SecretMasker
+	out     io.Writer
+	pending []byte
+	mutex   sync.Mutex
+
+	flushTimer *time.Timer
+	writeCount int // lets a delayed flush tell whether more output arrived after it was scheduled
+}
+
+func (w *MaskingWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	maskedOutput, heldBack := w.masker.mask(append(w.pending, p...), false)
+	w.pending = append([]byte{}, heldBack...)
+
+	w.writeCount++
+	if len(w.pending) > 0 {
+		w.scheduleFlush()
+	}
+
+	if len(maskedOutput) > 0 {
+		if _, err := w.out.Write(maskedOutput); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// scheduleFlush flushes the held back output once the writer has been idle for MASKING_WRITER_FLUSH_DELAY. The mutex must be held
+func (w *MaskingWriter) scheduleFlush() {
+	if w.flushTimer != nil {
+		w.flushTimer.Stop()
+	}
+
+	scheduledAt := w.writeCount
+	w.flushTimer = time.AfterFunc(MASKING_WRITER_FLUSH_DELAY, func() {
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+
+		if w.writeCount == scheduledAt {
+			w.flush()
+		}
+	})
+}
+
+// Flush writes out any output that was held back because it could have been the start of a secret
+func (w *MaskingWriter) Flush() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.flushTimer != nil {
+		w.flushTimer.Stop()
+	}
+
+	return w.flush()
+}
+
+func (w *MaskingWriter) flush() error {
+	maskedOutput, _ := w.masker.mask(w.pending, true)
+	w.pending = nil
+
+	if len(maskedOutput) == 0 {
+		return nil
+	}
+
+	_, err := w.out.Write(maskedOutput)
+	return err
+}
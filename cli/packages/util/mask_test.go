@@ -0,0 +1,116 @@
+package util
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaskingWriter(t *testing.T) {
+	secretValue := "s3cr3t/value+1"
+
+	tests := []struct {
+		name     string
+		writes   []string
+		expected string
+	}{
+		{
+			name:     "Raw value",
+			writes:   []string{"password=" + secretValue + "\n"},
+			expected: "password=***\n",
+		},
+		{
+			name:     "Value split across writes",
+			writes:   []string{"password=s3c", "r3t/va", "lue+1 done\n"},
+			expected: "password=*** done\n",
+		},
+		{
+			name:     "Base64 encoded value",
+			writes:   []string{"auth=" + base64.StdEncoding.EncodeToString([]byte(secretValue)) + "\n"},
+			expected: "auth=***\n",
+		},
+		{
+			name:     "URL encoded value",
+			writes:   []string{"https://host/?key=" + url.QueryEscape(secretValue) + "\n"},
+			expected: "https://host/?key=***\n",
+		},
+		{
+			name:     "Partial value at the end of the output",
+			writes:   []string{"not a secret: s3cr"},
+			expected: "not a secret: s3cr",
+		},
+		{
+			name:     "Longer secret sharing a prefix",
+			writes:   []string{"s3cr3t/value+1", "+extended\n"},
+			expected: "***\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masker := NewSecretMasker()
+			masker.AddSecrets([]models.SingleEnvironmentVariable{
+				{Key: "SECRET", Value: secretValue},
+				{Key: "EXTENDED_SECRET", Value: secretValue + "+extended"},
+				{Key: "SHORT", Value: "abc"},
+			})
+
+			output := &strings.Builder{}
+			writer := masker.NewWriter(output)
+			for _, write := range tt.writes {
+				n, err := writer.Write([]byte(write))
+				assert.NoError(t, err)
+				assert.Equal(t, len(write), n)
+			}
+			assert.NoError(t, writer.Flush())
+
+			assert.Equal(t, tt.expected, output.String())
+		})
+	}
+}
+
+// lockedBuffer is a strings.Builder that can be written from the flush timer and read from the test
+type lockedBuffer struct {
+	mutex   sync.Mutex
+	builder strings.Builder
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.builder.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.builder.String()
+}
+
+func TestMaskingWriterFlushesWhenIdle(t *testing.T) {
+	masker := NewSecretMasker()
+	masker.AddSecrets([]models.SingleEnvironmentVariable{{Key: "SECRET", Value: "Passw0rd!"}})
+
+	output := &lockedBuffer{}
+	writer := masker.NewWriter(output)
+
+	// the end of the prompt could be the start of the secret, so it is held back at first
+	_, err := writer.Write([]byte("Enter your Pass"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Enter your ", output.String())
+
+	assert.Eventually(t, func() bool { return output.String() == "Enter your Pass" }, 10*MASKING_WRITER_FLUSH_DELAY, 10*time.Millisecond)
+
+	// a secret split across writes that arrive in quick succession is still masked
+	_, err = writer.Write([]byte("\nPas"))
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte("sw0rd!\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Enter your Pass\n***\n", output.String())
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+const SECRETS_MOUNT_DIR_AUTO = "auto"
+
+// PrepareSecretsMountDir resolves the --mount-dir flag into a private directory that secrets can be written to.
+// With "auto" a memory backed location is preferred so secrets never reach the disk. An explicit directory must be empty or not exist yet.
+// The returned bool reports whether the directory was created by the CLI and can be removed as a whole
+func PrepareSecretsMountDir(mountDir string) (string, bool, error) {
+	if mountDir == SECRETS_MOUNT_DIR_AUTO {
+		for _, baseDir := range getMemoryBackedDirs() {
+			dir, err := os.MkdirTemp(baseDir, "infisical-secrets-")
+			if err == nil {
+				return dir, true, nil
+			}
+		}
+
+		dir, err := os.MkdirTemp("", "infisical-secrets-")
+		if err != nil {
+			return "", false, fmt.Errorf("unable to create secrets mount directory [err=%v]", err)
+		}
+
+		PrintWarning(fmt.Sprintf("No memory backed directory is available, secrets will be written to disk in [%s]", dir))
+		return dir, true, nil
+	}
+
+	dir, err := filepath.Abs(mountDir)
+	if err != nil {
+		return "", false, fmt.Errorf("unable to resolve secrets mount directory [err=%v]", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err == nil {
+		if len(entries) > 0 {
+			return "", false, fmt.Errorf("secrets mount directory [%s] must be empty", dir)
+		}
+
+		if err := os.Chmod(dir, 0700); err != nil {
+			return "", false, fmt.Errorf("unable to set permissions on secrets mount directory [err=%v]", err)
+		}
+
+		return dir, false, nil
+	}
+
+	if !os.IsNotExist(err) {
+		return "", false, fmt.Errorf("unable to read secrets mount directory [err=%v]", err)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", false, fmt.Errorf("unable to create secrets mount directory [err=%v]", err)
+	}
+
+	return dir, true, nil
+}
+
+// getMemoryBackedDirs returns directories that are usually backed by tmpfs, in order of preference
+func getMemoryBackedDirs() []string {
+	dirs := []string{}
+	if runtime.GOOS == "linux" {
+		dirs = append(dirs, "/dev/shm")
+	}
+
+	if xdgRuntimeDir := os.Getenv("XDG_RUNTIME_DIR"); xdgRuntimeDir != "" {
+		dirs = append(dirs, xdgRuntimeDir)
+	}
+
+	return dirs
+}
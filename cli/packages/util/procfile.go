@@ -0,0 +1,124 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+)
+
+var procfileEntryRegex = regexp.MustCompile(`^([A-Za-z0-9_-]+):\s*(.+)$`)
+
+// ParseProcfile reads the process names and commands from a Procfile. Blank lines and lines starting with # are ignored
+func ParseProcfile(procfilePath string) ([]models.ProcfileEntry, error) {
+	procfile, err := os.Open(procfilePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open Procfile [err=%v]", err)
+	}
+	defer procfile.Close()
+
+	return parseProcfile(procfile)
+}
+
+func parseProcfile(reader io.Reader) ([]models.ProcfileEntry, error) {
+	entries := []models.ProcfileEntry{}
+	seenNames := map[string]bool{}
+
+	scanner := bufio.NewScanner(reader)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		matches := procfileEntryRegex.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("invalid Procfile entry on line %d. Entries must be in the form name: command", lineNumber)
+		}
+
+		if seenNames[matches[1]] {
+			return nil, fmt.Errorf("duplicate Procfile entry [%s] on line %d", matches[1], lineNumber)
+		}
+		seenNames[matches[1]] = true
+
+		entries = append(entries, models.ProcfileEntry{Name: matches[1], Command: strings.TrimSpace(matches[2])})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read Procfile [err=%v]", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("the Procfile does not contain any entries")
+	}
+
+	return entries, nil
+}
+
+// PrefixedWriter writes every line it receives to out with a prefix.
+// Writers that share the same mutex never interleave their lines
+type PrefixedWriter struct {
+	out       io.Writer
+	outMutex  *sync.Mutex
+	prefix    string
+	buffer    []byte
+	bufferMux sync.Mutex
+}
+
+func NewPrefixedWriter(out io.Writer, outMutex *sync.Mutex, prefix string) *PrefixedWriter {
+	return &PrefixedWriter{
+		out:      out,
+		outMutex: outMutex,
+		prefix:   prefix,
+	}
+}
+
+func (w *PrefixedWriter) Write(p []byte) (int, error) {
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+
+	w.buffer = append(w.buffer, p...)
+	for {
+		newlineIndex := bytes.IndexByte(w.buffer, '\n')
+		if newlineIndex < 0 {
+			break
+		}
+
+		if err := w.writeLine(w.buffer[:newlineIndex+1]); err != nil {
+			return 0, err
+		}
+		w.buffer = w.buffer[newlineIndex+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes out a trailing line that did not end with a newline
+func (w *PrefixedWriter) Flush() error {
+	w.bufferMux.Lock()
+	defer w.bufferMux.Unlock()
+
+	if len(w.buffer) == 0 {
+		return nil
+	}
+
+	err := w.writeLine(append(w.buffer, '\n'))
+	w.buffer = nil
+	return err
+}
+
+func (w *PrefixedWriter) writeLine(line []byte) error {
+	w.outMutex.Lock()
+	defer w.outMutex.Unlock()
+
+	_, err := w.out.Write(append([]byte(w.prefix), line...))
+	return err
+}
@@ -0,0 +1,47 @@
+package util
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProcfile(t *testing.T) {
+	procfile := `
+# processes of the service
+web: npm run start -- --port 3000
+worker:   node worker.js
+
+scheduler_1: node scheduler.js
+`
+	entries, err := parseProcfile(strings.NewReader(procfile))
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ProcfileEntry{
+		{Name: "web", Command: "npm run start -- --port 3000"},
+		{Name: "worker", Command: "node worker.js"},
+		{Name: "scheduler_1", Command: "node scheduler.js"},
+	}, entries)
+
+	_, err = parseProcfile(strings.NewReader("web npm start"))
+	assert.Error(t, err)
+
+	_, err = parseProcfile(strings.NewReader("web: npm start\nweb: npm run dev"))
+	assert.Error(t, err)
+
+	_, err = parseProcfile(strings.NewReader("# nothing to run"))
+	assert.Error(t, err)
+}
+
+func TestPrefixedWriter(t *testing.T) {
+	output := &strings.Builder{}
+	writer := NewPrefixedWriter(output, &sync.Mutex{}, "web | ")
+
+	writer.Write([]byte("first line\nsecond "))
+	writer.Write([]byte("line\nlast line"))
+	writer.Flush()
+
+	assert.Equal(t, "web | first line\nweb | second line\nweb | last line\n", output.String())
+}
@@ -0,0 +1,119 @@
+package util
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+const SECRET_VERSIONS_PAGE_SIZE = 50
+
+// GetSecretVersions returns the versions of a secret, newest first
+func GetSecretVersions(httpClient *resty.Client, secretId string, offset int, limit int) ([]models.SecretVersion, error) {
+	response, err := api.CallGetSecretVersionsV1(httpClient, api.GetSecretVersionsV1Request{
+		SecretID: secretId,
+		Offset:   offset,
+		Limit:    limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make([]models.SecretVersion, 0, len(response.SecretVersions))
+	for _, secretVersion := range response.SecretVersions {
+		versions = append(versions, models.SecretVersion{
+			Version:   secretVersion.Version,
+			Value:     secretVersion.SecretValue,
+			Comment:   secretVersion.SecretComment,
+			CreatedAt: secretVersion.CreatedAt,
+		})
+	}
+
+	return versions, nil
+}
+
+// GetSecretVersion pages through the versions of a secret until it finds the given version
+func GetSecretVersion(httpClient *resty.Client, secretId string, version int) (models.SecretVersion, error) {
+	for offset := 0; ; offset += SECRET_VERSIONS_PAGE_SIZE {
+		versions, err := GetSecretVersions(httpClient, secretId, offset, SECRET_VERSIONS_PAGE_SIZE)
+		if err != nil {
+			return models.SecretVersion{}, err
+		}
+
+		for _, secretVersion := range versions {
+			if secretVersion.Version == version {
+				return secretVersion, nil
+			}
+		}
+
+		if len(versions) < SECRET_VERSIONS_PAGE_SIZE {
+			return models.SecretVersion{}, fmt.Errorf("version %d of the secret does not exist", version)
+		}
+	}
+}
+
+// AddSecretVersionActors sets the actor of each version from the audit logs of the project. The audit logs may be unavailable
+// to the caller or not go back far enough, so the actors are left empty when they cannot be found
+func AddSecretVersionActors(httpClient *resty.Client, projectId string, secretId string, versions []models.SecretVersion) {
+	if len(versions) == 0 {
+		return
+	}
+
+	startDate := versions[0].CreatedAt
+	for _, secretVersion := range versions {
+		if secretVersion.CreatedAt.Before(startDate) {
+			startDate = secretVersion.CreatedAt
+		}
+	}
+
+	response, err := api.CallGetAuditLogsV1(httpClient, api.GetAuditLogsV1Request{
+		ProjectID:     projectId,
+		EventTypes:    []string{"create-secret", "update-secret"},
+		EventMetadata: map[string]string{"secretId": secretId},
+		// the audit log is written after the version, allow for clock differences
+		StartDate: startDate.Add(-time.Minute),
+		Limit:     len(versions),
+	})
+	if err != nil {
+		log.Debug().Err(err).Msg("Unable to fetch the audit logs of the secret versions")
+		return
+	}
+
+	actorByVersion := getAuditLogActorsBySecretVersion(response.AuditLogs)
+	for index := range versions {
+		versions[index].Actor = actorByVersion[versions[index].Version]
+	}
+}
+
+func getAuditLogActorsBySecretVersion(auditLogs []api.AuditLog) map[int]string {
+	actorByVersion := map[int]string{}
+	for _, auditLog := range auditLogs {
+		// json numbers are decoded as float64
+		secretVersion, ok := auditLog.Event.Metadata["secretVersion"].(float64)
+		if !ok {
+			continue
+		}
+
+		actor := getAuditLogActorName(auditLog)
+		if actor != "" {
+			actorByVersion[int(secretVersion)] = actor
+		}
+	}
+
+	return actorByVersion
+}
+
+// getAuditLogActorName returns the email or username of a user, or the name of a machine identity or service token
+func getAuditLogActorName(auditLog api.AuditLog) string {
+	for _, field := range []string{"email", "username", "name"} {
+		if name, ok := auditLog.Actor.Metadata[field].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	return auditLog.Actor.Type
+}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuditLogActorsBySecretVersion(t *testing.T) {
+	var auditLogs []api.AuditLog
+	err := json.Unmarshal([]byte(`[
+		{"event": {"type": "update-secret", "metadata": {"secretVersion": 3}}, "actor": {"type": "user", "metadata": {"email": "jane@example.com", "username": "jane"}}},
+		{"event": {"type": "update-secret", "metadata": {"secretVersion": 2}}, "actor": {"type": "identity", "metadata": {"name": "ci"}}},
+		{"event": {"type": "create-secret", "metadata": {"secretVersion": 1}}, "actor": {"type": "platform", "metadata": {}}},
+		{"event": {"type": "update-secret", "metadata": {}}, "actor": {"type": "user", "metadata": {"email": "other@example.com"}}}
+	]`), &auditLogs)
+	assert.NoError(t, err)
+
+	assert.Equal(t, map[int]string{
+		3: "jane@example.com",
+		2: "ci",
+		1: "platform",
+	}, getAuditLogActorsBySecretVersion(auditLogs))
+}
@@ -0,0 +1,216 @@
+package util
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/go-resty/resty/v2"
+)
+
+const (
+	SECRETS_BATCH_SIZE               = 100
+	DEFAULT_SECRET_WRITE_CONCURRENCY = 10
+)
+
+// secretsWriter writes secrets to a single folder. Shared secrets are written with the batch endpoints. Personal secrets, which the
+// batch create and update endpoints do not accept, and instances without the batch endpoints fall back to one request per secret.
+// At most concurrency requests are in flight at once
+type secretsWriter struct {
+	httpClient  *resty.Client
+	projectId   string
+	environment string
+	secretsPath string
+	concurrency int
+	// batchUnsupported is set once the instance answers 404 to a batch request
+	batchUnsupported atomic.Bool
+}
+
+func newSecretsWriter(httpClient *resty.Client, projectId string, environment string, secretsPath string, concurrency int) *secretsWriter {
+	if concurrency < 1 {
+		concurrency = DEFAULT_SECRET_WRITE_CONCURRENCY
+	}
+
+	return &secretsWriter{
+		httpClient:  httpClient,
+		projectId:   projectId,
+		environment: environment,
+		secretsPath: secretsPath,
+		concurrency: concurrency,
+	}
+}
+
+// DeleteRawSecrets deletes the secrets by name and returns the error of each secret, at the same index
+func DeleteRawSecrets(httpClient *resty.Client, secretNames []string, secretType string, environmentName string, secretsPath string, projectId string, concurrency int) []error {
+	secrets := make([]api.RawSecret, 0, len(secretNames))
+	for _, secretName := range secretNames {
+		secrets = append(secrets, api.RawSecret{SecretKey: secretName, Type: secretType})
+	}
+
+	return newSecretsWriter(httpClient, projectId, environmentName, secretsPath, concurrency).deleteSecrets(secrets)
+}
+
+// createSecrets creates the secrets and returns the error of each secret, at the same index
+func (w *secretsWriter) createSecrets(secrets []api.RawSecret) []error {
+	return w.write(secrets, true, func(batch []api.RawSecret) error {
+		return api.CallCreateRawSecretsBatchV3(w.httpClient, api.BatchCreateRawSecretsV3Request{
+			WorkspaceID: w.projectId,
+			Environment: w.environment,
+			SecretPath:  w.secretsPath,
+			Secrets:     toBatchRawSecrets(batch),
+		})
+	}, func(secret api.RawSecret) error {
+		var tagIDs []string
+		if secret.Metadata.TagIDs != nil {
+			tagIDs = *secret.Metadata.TagIDs
+		}
+
+		var secretComment string
+		if secret.Metadata.SecretComment != nil {
+			secretComment = *secret.Metadata.SecretComment
+		}
+
+		return api.CallCreateRawSecretsV3(w.httpClient, api.CreateRawSecretV3Request{
+			SecretName:    secret.SecretKey,
+			SecretValue:   secret.SecretValue,
+			Type:          secret.Type,
+			SecretPath:    w.secretsPath,
+			WorkspaceID:   w.projectId,
+			Environment:   w.environment,
+			SecretComment: secretComment,
+			TagIDs:        tagIDs,
+		})
+	})
+}
+
+// updateSecrets updates the values and metadata of the secrets and returns the error of each secret, at the same index
+func (w *secretsWriter) updateSecrets(secrets []api.RawSecret) []error {
+	return w.write(secrets, true, func(batch []api.RawSecret) error {
+		return api.CallUpdateRawSecretsBatchV3(w.httpClient, api.BatchUpdateRawSecretsV3Request{
+			WorkspaceID: w.projectId,
+			Environment: w.environment,
+			SecretPath:  w.secretsPath,
+			Secrets:     toBatchRawSecrets(batch),
+		})
+	}, func(secret api.RawSecret) error {
+		return api.CallUpdateRawSecretsV3(w.httpClient, api.UpdateRawSecretByNameV3Request{
+			SecretName:        secret.SecretKey,
+			SecretValue:       secret.SecretValue,
+			SecretPath:        w.secretsPath,
+			WorkspaceID:       w.projectId,
+			Environment:       w.environment,
+			Type:              secret.Type,
+			RawSecretMetadata: secret.Metadata,
+		})
+	})
+}
+
+// deleteSecrets deletes the secrets and returns the error of each secret, at the same index
+func (w *secretsWriter) deleteSecrets(secrets []api.RawSecret) []error {
+	return w.write(secrets, false, func(batch []api.RawSecret) error {
+		secretsToDelete := make([]api.BatchDeleteRawSecret, 0, len(batch))
+		for _, secret := range batch {
+			secretsToDelete = append(secretsToDelete, api.BatchDeleteRawSecret{SecretKey: secret.SecretKey, Type: secret.Type})
+		}
+
+		return api.CallDeleteRawSecretsBatchV3(w.httpClient, api.BatchDeleteRawSecretsV3Request{
+			WorkspaceID: w.projectId,
+			Environment: w.environment,
+			SecretPath:  w.secretsPath,
+			Secrets:     secretsToDelete,
+		})
+	}, func(secret api.RawSecret) error {
+		return api.CallDeleteSecretsRawV3(w.httpClient, api.DeleteSecretV3Request{
+			SecretName:  secret.SecretKey,
+			WorkspaceId: w.projectId,
+			Environment: w.environment,
+			Type:        secret.Type,
+			SecretPath:  w.secretsPath,
+		})
+	})
+}
+
+// write sends the secrets in batches of SECRETS_BATCH_SIZE, then one by one for the secrets that cannot be batched. A failed batch
+// fails every secret in it, as the batch endpoints write all of the secrets of a batch or none of them
+func (w *secretsWriter) write(secrets []api.RawSecret, sharedOnly bool, writeBatch func([]api.RawSecret) error, writeSingle func(api.RawSecret) error) []error {
+	errs := make([]error, len(secrets))
+
+	batches := [][]int{}
+	singles := []int{}
+	for index, secret := range secrets {
+		if sharedOnly && secret.Type == SECRET_TYPE_PERSONAL {
+			singles = append(singles, index)
+			continue
+		}
+
+		if len(batches) == 0 || len(batches[len(batches)-1]) == SECRETS_BATCH_SIZE {
+			batches = append(batches, []int{})
+		}
+		batches[len(batches)-1] = append(batches[len(batches)-1], index)
+	}
+
+	unsupportedBatches := make([]bool, len(batches))
+	runConcurrently(w.concurrency, len(batches), func(batchIndex int) {
+		if w.batchUnsupported.Load() {
+			unsupportedBatches[batchIndex] = true
+			return
+		}
+
+		batch := make([]api.RawSecret, 0, len(batches[batchIndex]))
+		for _, index := range batches[batchIndex] {
+			batch = append(batch, secrets[index])
+		}
+
+		err := writeBatch(batch)
+		var responseErr *api.UnsuccessfulResponseError
+		if errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusNotFound {
+			w.batchUnsupported.Store(true)
+			unsupportedBatches[batchIndex] = true
+			return
+		}
+
+		for _, index := range batches[batchIndex] {
+			errs[index] = err
+		}
+	})
+
+	for batchIndex, unsupported := range unsupportedBatches {
+		if unsupported {
+			singles = append(singles, batches[batchIndex]...)
+		}
+	}
+
+	runConcurrently(w.concurrency, len(singles), func(singleIndex int) {
+		errs[singles[singleIndex]] = writeSingle(secrets[singles[singleIndex]])
+	})
+
+	return errs
+}
+
+func toBatchRawSecrets(secrets []api.RawSecret) []api.BatchRawSecret {
+	batchSecrets := make([]api.BatchRawSecret, 0, len(secrets))
+	for _, secret := range secrets {
+		batchSecrets = append(batchSecrets, api.BatchRawSecret{SecretKey: secret.SecretKey, SecretValue: secret.SecretValue, RawSecretMetadata: secret.Metadata})
+	}
+	return batchSecrets
+}
+
+// runConcurrently calls task for every index from 0 to count, with at most concurrency calls running at once
+func runConcurrently(concurrency int, count int, task func(index int)) {
+	semaphore := make(chan struct{}, concurrency)
+	waitGroup := sync.WaitGroup{}
+
+	for index := 0; index < count; index++ {
+		waitGroup.Add(1)
+		semaphore <- struct{}{}
+		go func(index int) {
+			defer waitGroup.Done()
+			defer func() { <-semaphore }()
+			task(index)
+		}(index)
+	}
+
+	waitGroup.Wait()
+}
@@ -0,0 +1,73 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/config"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSecretsWriter(t *testing.T, handler http.HandlerFunc) *secretsWriter {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	previousUrl := config.INFISICAL_URL
+	config.INFISICAL_URL = server.URL
+	t.Cleanup(func() { config.INFISICAL_URL = previousUrl })
+
+	return newSecretsWriter(resty.New(), "project-id", "dev", "/", 4)
+}
+
+func TestSecretsWriterBatches(t *testing.T) {
+	var lock sync.Mutex
+	requests := map[string]int{}
+	writer := newTestSecretsWriter(t, func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		requests[r.Method+" "+r.URL.Path]++
+		w.Write([]byte("{}"))
+	})
+
+	secrets := []api.RawSecret{{SecretKey: "PERSONAL", SecretValue: "value", Type: SECRET_TYPE_PERSONAL}}
+	for index := 0; index < 2*SECRETS_BATCH_SIZE+1; index++ {
+		secrets = append(secrets, api.RawSecret{SecretKey: fmt.Sprintf("KEY_%d", index), SecretValue: "value", Type: SECRET_TYPE_SHARED})
+	}
+
+	errs := writer.createSecrets(secrets)
+	assert.Len(t, errs, len(secrets))
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]int{
+		"POST /v3/secrets/batch/raw":    3,
+		"POST /v3/secrets/raw/PERSONAL": 1,
+	}, requests)
+}
+
+func TestSecretsWriterFallsBackWithoutBatchEndpoints(t *testing.T) {
+	writer := newTestSecretsWriter(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v3/secrets/batch/raw":
+			w.WriteHeader(http.StatusNotFound)
+		case "/v3/secrets/raw/INVALID":
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		w.Write([]byte("{}"))
+	})
+
+	errs := writer.updateSecrets([]api.RawSecret{
+		{SecretKey: "VALID", SecretValue: "value"},
+		{SecretKey: "INVALID", SecretValue: "value"},
+	})
+
+	assert.NoError(t, errs[0])
+	assert.ErrorContains(t, errs[1], "status-code=400")
+	assert.True(t, writer.batchUnsupported.Load())
+}
@@ -8,6 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -63,7 +67,7 @@ func GetPlainTextSecretsViaServiceToken(fullServiceToken string, environment str
 	plainTextSecrets := []models.SingleEnvironmentVariable{}
 
 	for _, secret := range rawSecrets.Secrets {
-		plainTextSecrets = append(plainTextSecrets, models.SingleEnvironmentVariable{Key: secret.SecretKey, Value: secret.SecretValue, Type: secret.Type, WorkspaceId: secret.Workspace})
+		plainTextSecrets = append(plainTextSecrets, rawSecretToEnvironmentVariable(secret))
 	}
 
 	if includeImports {
@@ -104,7 +108,7 @@ func GetPlainTextSecretsV3(accessToken string, workspaceId string, environmentNa
 	plainTextSecrets := []models.SingleEnvironmentVariable{}
 
 	for _, secret := range rawSecrets.Secrets {
-		plainTextSecrets = append(plainTextSecrets, models.SingleEnvironmentVariable{Key: secret.SecretKey, Value: secret.SecretValue, Type: secret.Type, WorkspaceId: secret.Workspace, SecretPath: secret.SecretPath})
+		plainTextSecrets = append(plainTextSecrets, rawSecretToEnvironmentVariable(secret))
 	}
 
 	if includeImports {
@@ -151,6 +155,29 @@ func GetSinglePlainTextSecretByNameV3(accessToken string, workspaceId string, en
 	return formattedSecrets, rawSecret.ETag, nil
 }
 
+func rawSecretToEnvironmentVariable(secret api.RawSecretV3) models.SingleEnvironmentVariable {
+	environmentVariable := models.SingleEnvironmentVariable{
+		Key:         secret.SecretKey,
+		Value:       secret.SecretValue,
+		Type:        secret.Type,
+		WorkspaceId: secret.Workspace,
+		SecretPath:  secret.SecretPath,
+		ID:          secret.ID,
+		Comment:     secret.SecretComment,
+	}
+
+	for _, tag := range secret.Tags {
+		environmentVariable.Tags = append(environmentVariable.Tags, struct {
+			ID        string `json:"_id"`
+			Name      string `json:"name"`
+			Slug      string `json:"slug"`
+			Workspace string `json:"workspace"`
+		}{ID: tag.ID, Name: tag.Name, Slug: tag.Slug, Workspace: secret.Workspace})
+	}
+
+	return environmentVariable
+}
+
 func CreateDynamicSecretLease(accessToken string, projectSlug string, environmentName string, secretsPath string, slug string, ttl string) (models.DynamicSecretLease, error) {
 	httpClient := resty.New()
 	httpClient.SetAuthToken(accessToken).
@@ -230,6 +257,237 @@ func FilterSecretsByTag(plainTextSecrets []models.SingleEnvironmentVariable, tag
 	return filteredSecrets
 }
 
+var (
+	validEnvVarNameRegex   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	invalidEnvVarCharRegex = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+)
+
+// TransformSecretKeys filters secrets by key and renames them according to the given options.
+// It also returns the original key of every renamed secret, keyed by its new name
+func TransformSecretKeys(secrets []models.SingleEnvironmentVariable, options models.SecretKeyTransformOptions) ([]models.SingleEnvironmentVariable, map[string]string, error) {
+	transformedSecrets := []models.SingleEnvironmentVariable{}
+	originalKeyByKey := map[string]string{}
+	indexByKey := map[string]int{}
+
+	for _, secret := range secrets {
+		shouldKeep, err := matchesSecretKeyFilters(secret.Key, options.Include, options.Exclude)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !shouldKeep {
+			continue
+		}
+
+		originalKey := secret.Key
+		secret.Key = transformSecretKey(originalKey, options)
+
+		if index, ok := indexByKey[secret.Key]; ok {
+			PrintWarning(fmt.Sprintf("Infisical secrets named [%v] and [%v] are both renamed to [%v], the value of [%v] will be used", originalKeyByKey[secret.Key], originalKey, secret.Key, originalKey))
+			transformedSecrets[index] = secret
+			originalKeyByKey[secret.Key] = originalKey
+			continue
+		}
+
+		indexByKey[secret.Key] = len(transformedSecrets)
+		originalKeyByKey[secret.Key] = originalKey
+		transformedSecrets = append(transformedSecrets, secret)
+	}
+
+	return transformedSecrets, originalKeyByKey, nil
+}
+
+func matchesSecretKeyFilters(key string, include []string, exclude []string) (bool, error) {
+	for _, pattern := range exclude {
+		matched, err := path.Match(pattern, key)
+		if err != nil {
+			return false, fmt.Errorf("invalid exclude pattern %q [err=%v]", pattern, err)
+		}
+		if matched {
+			return false, nil
+		}
+	}
+
+	if len(include) == 0 {
+		return true, nil
+	}
+
+	for _, pattern := range include {
+		matched, err := path.Match(pattern, key)
+		if err != nil {
+			return false, fmt.Errorf("invalid include pattern %q [err=%v]", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// transformSecretKey applies an explicit mapping if there is one, otherwise the prefix and sanitisation options
+func transformSecretKey(key string, options models.SecretKeyTransformOptions) string {
+	if mappedKey, ok := options.KeyMappings[key]; ok {
+		return mappedKey
+	}
+
+	newKey := strings.TrimPrefix(key, options.StripPrefix)
+	if options.SanitizeKeys && !IsValidEnvVarName(newKey) {
+		sanitizedKey := SanitizeEnvVarName(newKey)
+		PrintWarning(fmt.Sprintf("Infisical secret named [%v] is not a valid environment variable name and has been renamed to [%v]", key, sanitizedKey))
+		newKey = sanitizedKey
+	}
+
+	return options.Prefix + newKey
+}
+
+func IsValidEnvVarName(key string) bool {
+	return validEnvVarNameRegex.MatchString(key)
+}
+
+// SanitizeEnvVarName turns a key such as db.host or api-key into DB_HOST or API_KEY
+func SanitizeEnvVarName(key string) string {
+	sanitizedKey := strings.ToUpper(invalidEnvVarCharRegex.ReplaceAllString(key, "_"))
+	if sanitizedKey == "" || unicode.IsDigit(rune(sanitizedKey[0])) {
+		sanitizedKey = "_" + sanitizedKey
+	}
+
+	return sanitizedKey
+}
+
+// DiffSecrets compares two sets of secrets by key and returns the sorted names of the secrets that were added, removed or modified
+func DiffSecrets(oldSecrets []models.SingleEnvironmentVariable, newSecrets []models.SingleEnvironmentVariable) models.SecretsDiff {
+	oldSecretsByKey := getSecretsByKeys(oldSecrets)
+	newSecretsByKey := getSecretsByKeys(newSecrets)
+
+	diff := models.SecretsDiff{Added: []string{}, Removed: []string{}, Modified: []string{}}
+	for key, newSecret := range newSecretsByKey {
+		oldSecret, ok := oldSecretsByKey[key]
+		if !ok {
+			diff.Added = append(diff.Added, key)
+		} else if oldSecret.Value != newSecret.Value {
+			diff.Modified = append(diff.Modified, key)
+		}
+	}
+
+	for key := range oldSecretsByKey {
+		if _, ok := newSecretsByKey[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+
+	return diff
+}
+
+// CompareSecrets compares the secrets of two sides by key. Keys only on the from side are missing, keys only on the to side are extra.
+// The comparisons are sorted by key and hold the values of both sides
+func CompareSecrets(fromSecrets []models.SingleEnvironmentVariable, toSecrets []models.SingleEnvironmentVariable) []models.SecretComparison {
+	diff := DiffSecrets(fromSecrets, toSecrets)
+	fromSecretsByKey := getSecretsByKeys(fromSecrets)
+	toSecretsByKey := getSecretsByKeys(toSecrets)
+
+	comparisons := []models.SecretComparison{}
+	for _, key := range diff.Removed {
+		comparisons = append(comparisons, models.SecretComparison{Key: key, Status: SECRET_COMPARISON_MISSING, FromValue: fromSecretsByKey[key].Value})
+	}
+	for _, key := range diff.Added {
+		comparisons = append(comparisons, models.SecretComparison{Key: key, Status: SECRET_COMPARISON_EXTRA, ToValue: toSecretsByKey[key].Value})
+	}
+	for key, fromSecret := range fromSecretsByKey {
+		toSecret, ok := toSecretsByKey[key]
+		if !ok {
+			continue
+		}
+
+		status := SECRET_COMPARISON_IDENTICAL
+		if fromSecret.Value != toSecret.Value {
+			status = SECRET_COMPARISON_DIFFERENT
+		}
+		comparisons = append(comparisons, models.SecretComparison{Key: key, Status: status, FromValue: fromSecret.Value, ToValue: toSecret.Value})
+	}
+
+	sort.Slice(comparisons, func(i, j int) bool {
+		return comparisons[i].Key < comparisons[j].Key
+	})
+
+	return comparisons
+}
+
+// FilterSecretsDiff keeps the keys of the diff that match one of the include patterns, if any are given, and none of the exclude patterns
+func FilterSecretsDiff(diff models.SecretsDiff, include []string, exclude []string) (models.SecretsDiff, error) {
+	filterKeys := func(keys []string) ([]string, error) {
+		filteredKeys := []string{}
+		for _, key := range keys {
+			matched, err := matchesSecretKeyFilters(key, include, exclude)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				filteredKeys = append(filteredKeys, key)
+			}
+		}
+		return filteredKeys, nil
+	}
+
+	var filteredDiff models.SecretsDiff
+	var err error
+	if filteredDiff.Added, err = filterKeys(diff.Added); err != nil {
+		return models.SecretsDiff{}, err
+	}
+	if filteredDiff.Removed, err = filterKeys(diff.Removed); err != nil {
+		return models.SecretsDiff{}, err
+	}
+	if filteredDiff.Modified, err = filterKeys(diff.Modified); err != nil {
+		return models.SecretsDiff{}, err
+	}
+
+	return filteredDiff, nil
+}
+
+// SecretsDiffKeys returns the names of all added, removed and modified secrets of the diff
+func SecretsDiffKeys(diff models.SecretsDiff) []string {
+	keys := make([]string, 0, len(diff.Added)+len(diff.Removed)+len(diff.Modified))
+	keys = append(keys, diff.Added...)
+	keys = append(keys, diff.Removed...)
+	return append(keys, diff.Modified...)
+}
+
+// DescribeSecretsDiff summarises the names of the changed secrets, e.g. "added [A], modified [B, C]". Values are never included
+func DescribeSecretsDiff(diff models.SecretsDiff) string {
+	changes := []string{}
+	if len(diff.Added) > 0 {
+		changes = append(changes, fmt.Sprintf("added [%s]", strings.Join(diff.Added, ", ")))
+	}
+	if len(diff.Removed) > 0 {
+		changes = append(changes, fmt.Sprintf("removed [%s]", strings.Join(diff.Removed, ", ")))
+	}
+	if len(diff.Modified) > 0 {
+		changes = append(changes, fmt.Sprintf("modified [%s]", strings.Join(diff.Modified, ", ")))
+	}
+
+	if len(changes) == 0 {
+		return "no secret values changed"
+	}
+	return strings.Join(changes, ", ")
+}
+
+// ParseSecretKeyMappings parses OLD=NEW pairs into a map of renames
+func ParseSecretKeyMappings(mappings []string) (map[string]string, error) {
+	keyMappings := map[string]string{}
+	for _, mapping := range mappings {
+		oldKey, newKey, found := strings.Cut(mapping, "=")
+		if !found || oldKey == "" || newKey == "" {
+			return nil, fmt.Errorf("invalid key mapping %q. Key mappings must be in the form OLD=NEW", mapping)
+		}
+		keyMappings[oldKey] = newKey
+	}
+
+	return keyMappings, nil
+}
+
 func GetAllEnvironmentVariables(params models.GetAllSecretsParameters, projectConfigFilePath string) ([]models.SingleEnvironmentVariable, error) {
 	var secretsToReturn []models.SingleEnvironmentVariable
 	// var serviceTokenDetails api.GetServiceTokenDetailsResponse
@@ -331,6 +589,166 @@ func GetAllEnvironmentVariables(params models.GetAllSecretsParameters, projectCo
 	return secretsToReturn, errorToReturn
 }
 
+// ParseSecretSource parses a source in the form project:env:path[:recursive].
+// Any part left empty falls back to the value in defaults, so ":prod:/api" only changes the environment and path
+func ParseSecretSource(source string, defaults models.GetAllSecretsParameters) (models.GetAllSecretsParameters, error) {
+	parts := strings.Split(source, ":")
+	if len(parts) < 3 || len(parts) > 4 {
+		return models.GetAllSecretsParameters{}, fmt.Errorf("invalid source %q. Sources must be in the form project:env:path[:recursive]", source)
+	}
+
+	params := defaults
+	if parts[0] != "" {
+		params.WorkspaceId = parts[0]
+	}
+
+	if parts[1] != "" {
+		params.Environment = parts[1]
+	}
+
+	if parts[2] != "" {
+		params.SecretsPath = parts[2]
+	}
+
+	if len(parts) == 4 {
+		switch strings.ToLower(parts[3]) {
+		case "recursive", "true":
+			params.Recursive = true
+		case "", "false":
+			params.Recursive = false
+		default:
+			return models.GetAllSecretsParameters{}, fmt.Errorf("invalid source %q. The last part of a source can only be 'recursive'", source)
+		}
+	}
+
+	return params, nil
+}
+
+// DescribeSecretSource returns a short, human readable name for a source that is used in logs
+func DescribeSecretSource(params models.GetAllSecretsParameters) string {
+	description := fmt.Sprintf("%s:%s:%s", params.WorkspaceId, params.Environment, params.SecretsPath)
+	if params.Recursive {
+		description += ":recursive"
+	}
+	return description
+}
+
+// GetAllEnvironmentVariablesFromSources fetches the secrets of every source in order and layers them on top of each other.
+// When the same key exists in more than one source, the source that comes later wins
+func GetAllEnvironmentVariablesFromSources(sources []models.GetAllSecretsParameters, projectConfigFilePath string, secretOverriding bool) (models.MergedSecretsResult, error) {
+	secretsByKey := make(map[string]models.SingleEnvironmentVariable)
+	sourceByKey := make(map[string]string)
+	sourceETags := make([]string, 0, len(sources))
+
+	for _, source := range sources {
+		secrets, err := GetAllEnvironmentVariables(source, projectConfigFilePath)
+		if err != nil {
+			return models.MergedSecretsResult{}, fmt.Errorf("unable to fetch secrets from source %s [err=%v]", DescribeSecretSource(source), err)
+		}
+
+		if secretOverriding {
+			secrets = OverrideSecrets(secrets, SECRET_TYPE_PERSONAL)
+		} else {
+			secrets = OverrideSecrets(secrets, SECRET_TYPE_SHARED)
+		}
+
+		sourceETags = append(sourceETags, GenerateETagFromSecrets(secrets))
+
+		sourceDescription := DescribeSecretSource(source)
+		for _, secret := range secrets {
+			secretsByKey[secret.Key] = secret
+			sourceByKey[secret.Key] = sourceDescription
+		}
+	}
+
+	mergedSecrets := make([]models.SingleEnvironmentVariable, 0, len(secretsByKey))
+	for _, secret := range secretsByKey {
+		mergedSecrets = append(mergedSecrets, secret)
+	}
+
+	return models.MergedSecretsResult{
+		Secrets:     SortSecretsByKeys(mergedSecrets),
+		SourceByKey: sourceByKey,
+		ETag:        GetHashFromStringList(sourceETags),
+	}, nil
+}
+
+// GetAllSecretsFromSourcesByFolder fetches the secrets of every source like GetAllEnvironmentVariablesFromSources, but keeps secrets with the same key in different folders apart.
+// The SecretPath of every returned secret is relative to the path of its source, e.g. /db for a secret in /app/db fetched recursively from /app.
+// Imported secrets are placed in the root folder
+func GetAllSecretsFromSourcesByFolder(sources []models.GetAllSecretsParameters, projectConfigFilePath string, secretOverriding bool) ([]models.SingleEnvironmentVariable, error) {
+	type folderKey struct {
+		folder string
+		key    string
+	}
+	secretsByFolderKey := make(map[folderKey]models.SingleEnvironmentVariable)
+
+	for _, source := range sources {
+		secrets, err := GetAllEnvironmentVariables(source, projectConfigFilePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to fetch secrets from source %s [err=%v]", DescribeSecretSource(source), err)
+		}
+
+		for folder, folderSecrets := range GroupSecretsByFolder(secrets) {
+			if secretOverriding {
+				folderSecrets = OverrideSecrets(folderSecrets, SECRET_TYPE_PERSONAL)
+			} else {
+				folderSecrets = OverrideSecrets(folderSecrets, SECRET_TYPE_SHARED)
+			}
+
+			relativeFolder := GetRelativeSecretPath(source.SecretsPath, folder)
+			for _, secret := range folderSecrets {
+				secret.SecretPath = relativeFolder
+				secretsByFolderKey[folderKey{folder: relativeFolder, key: secret.Key}] = secret
+			}
+		}
+	}
+
+	mergedSecrets := make([]models.SingleEnvironmentVariable, 0, len(secretsByFolderKey))
+	for _, secret := range secretsByFolderKey {
+		mergedSecrets = append(mergedSecrets, secret)
+	}
+
+	sort.Slice(mergedSecrets, func(i, j int) bool {
+		if mergedSecrets[i].SecretPath != mergedSecrets[j].SecretPath {
+			return mergedSecrets[i].SecretPath < mergedSecrets[j].SecretPath
+		}
+		return mergedSecrets[i].Key < mergedSecrets[j].Key
+	})
+
+	return mergedSecrets, nil
+}
+
+// GroupSecretsByFolder groups secrets by their SecretPath, keeping their order within each folder
+func GroupSecretsByFolder(secrets []models.SingleEnvironmentVariable) map[string][]models.SingleEnvironmentVariable {
+	secretsByFolder := make(map[string][]models.SingleEnvironmentVariable)
+	for _, secret := range secrets {
+		secretsByFolder[secret.SecretPath] = append(secretsByFolder[secret.SecretPath], secret)
+	}
+	return secretsByFolder
+}
+
+// GetRelativeSecretPath returns the folder relative to the root folder, starting with a slash. Folders outside of the root, such as
+// the empty path of imported secrets, are returned as the root itself ("/")
+func GetRelativeSecretPath(root string, folder string) string {
+	root = path.Clean("/" + root)
+	if folder == "" {
+		return "/"
+	}
+	folder = path.Clean("/" + folder)
+
+	if root == "/" {
+		return folder
+	}
+	if folder == root {
+		return "/"
+	}
+	if strings.HasPrefix(folder, root+"/") {
+		return strings.TrimPrefix(folder, root)
+	}
+	return "/"
+}
+
 func getSecretsByKeys(secrets []models.SingleEnvironmentVariable) map[string]models.SingleEnvironmentVariable {
 	secretMapByName := make(map[string]models.SingleEnvironmentVariable, len(secrets))
 
@@ -564,7 +982,11 @@ func GetPlainTextWorkspaceKey(authenticationToken string, receiverPrivateKey str
 	return crypto.DecryptAsymmetric(encryptedWorkspaceKey, encryptedWorkspaceKeyNonce, encryptedWorkspaceKeySenderPublicKey, currentUsersPrivateKey), nil
 }
 
-func SetRawSecrets(secretArgs []string, secretType string, environmentName string, secretsPath string, projectId string, tokenDetails *models.TokenDetails) ([]models.SecretSetOperation, error) {
+// SetRawSecrets creates the secrets that do not exist yet and updates the ones whose value or metadata changed. With options.DryRun, the
+// operations are only computed and returned, nothing is written. When some writes fail, the operations are returned along with an error,
+// and the failed operations hold the error of the write. With options.Atomic, the writes that succeeded are then rolled back. A rollback
+// restores the value, comment and tags of the modified secrets but not their reminder, which cannot be read back
+func SetRawSecrets(secretsToSet []models.SingleEnvironmentVariable, secretType string, environmentName string, secretsPath string, projectId string, tokenDetails *models.TokenDetails, options models.SetSecretsOptions) ([]models.SecretSetOperation, error) {
 
 	if tokenDetails == nil {
 		return nil, fmt.Errorf("unable to process set secret operations, token details are missing")
@@ -593,6 +1015,11 @@ func SetRawSecrets(secretArgs []string, secretType string, environmentName strin
 	secretsToModify := []api.RawSecret{}
 	secretOperations := []models.SecretSetOperation{}
 
+	// the index in secretOperations of each secret to create and modify, and the values the modified secrets had before
+	createOperationIndexes := []int{}
+	modifyOperationIndexes := []int{}
+	previousSecrets := []api.RawSecret{}
+
 	sharedSecretMapByName := make(map[string]models.SingleEnvironmentVariable, len(secrets))
 	personalSecretMapByName := make(map[string]models.SingleEnvironmentVariable, len(secrets))
 
@@ -604,19 +1031,9 @@ func SetRawSecrets(secretArgs []string, secretType string, environmentName strin
 		}
 	}
 
-	for _, arg := range secretArgs {
-		splitKeyValueFromArg := strings.SplitN(arg, "=", 2)
-		if splitKeyValueFromArg[0] == "" || splitKeyValueFromArg[1] == "" {
-			PrintErrorMessageAndExit("ensure that each secret has a none empty key and value. Modify the input and try again")
-		}
-
-		if unicode.IsNumber(rune(splitKeyValueFromArg[0][0])) {
-			PrintErrorMessageAndExit("keys of secrets cannot start with a number. Modify the key name(s) and try again")
-		}
-
-		// Key and value from argument
-		key := splitKeyValueFromArg[0]
-		value := splitKeyValueFromArg[1]
+	for _, secretToSet := range secretsToSet {
+		key := secretToSet.Key
+		value := secretToSet.Value
 
 		var existingSecret models.SingleEnvironmentVariable
 		var doesSecretExist bool
@@ -629,20 +1046,29 @@ func SetRawSecrets(secretArgs []string, secretType string, environmentName strin
 
 		if doesSecretExist {
 			// case: secret exists in project so it needs to be modified
+			metadata, previousMetadata := getSecretMetadataChanges(existingSecret, options.Metadata)
 			encryptedSecretDetails := api.RawSecret{
 				ID:          existingSecret.ID,
 				SecretValue: value,
 				SecretKey:   key,
 				Type:        existingSecret.Type,
+				Metadata:    metadata,
 			}
 
-			// Only add to modifications if the value is different
-			if existingSecret.Value != value {
+			// Only add to modifications if the value or the metadata is different
+			if existingSecret.Value != value || metadata != (api.RawSecretMetadata{}) {
+				secretOperation := "SECRET VALUE MODIFIED"
+				if existingSecret.Value == value {
+					secretOperation = "SECRET METADATA MODIFIED"
+				}
+
 				secretsToModify = append(secretsToModify, encryptedSecretDetails)
+				previousSecrets = append(previousSecrets, api.RawSecret{SecretKey: key, SecretValue: existingSecret.Value, Type: existingSecret.Type, Metadata: previousMetadata})
+				modifyOperationIndexes = append(modifyOperationIndexes, len(secretOperations))
 				secretOperations = append(secretOperations, models.SecretSetOperation{
 					SecretKey:       key,
 					SecretValue:     value,
-					SecretOperation: "SECRET VALUE MODIFIED",
+					SecretOperation: secretOperation,
 				})
 			} else {
 				// Current value is same as existing so no change
@@ -655,12 +1081,19 @@ func SetRawSecrets(secretArgs []string, secretType string, environmentName strin
 
 		} else {
 			// case: secret doesn't exist in project so it needs to be created
+			metadata, _ := getSecretMetadataChanges(models.SingleEnvironmentVariable{}, options.Metadata)
+			// the create endpoints do not take reminders, they are set once the secret is created
+			metadata.SecretReminderNote = nil
+			metadata.SecretReminderRepeatDays = nil
+
 			encryptedSecretDetails := api.RawSecret{
 				SecretKey:   key,
 				SecretValue: value,
 				Type:        secretType,
+				Metadata:    metadata,
 			}
 			secretsToCreate = append(secretsToCreate, encryptedSecretDetails)
+			createOperationIndexes = append(createOperationIndexes, len(secretOperations))
 			secretOperations = append(secretOperations, models.SecretSetOperation{
 				SecretKey:       key,
 				SecretValue:     value,
@@ -669,38 +1102,149 @@ func SetRawSecrets(secretArgs []string, secretType string, environmentName strin
 		}
 	}
 
-	for _, secret := range secretsToCreate {
-		createSecretRequest := api.CreateRawSecretV3Request{
-			SecretName:  secret.SecretKey,
-			SecretValue: secret.SecretValue,
-			Type:        secret.Type,
-			SecretPath:  secretsPath,
-			WorkspaceID: projectId,
-			Environment: environmentName,
-		}
+	if options.DryRun {
+		return secretOperations, nil
+	}
+
+	writer := newSecretsWriter(httpClient, projectId, environmentName, secretsPath, options.Concurrency)
 
-		err = api.CallCreateRawSecretsV3(httpClient, createSecretRequest)
+	failedCount := 0
+	createdSecrets := []api.RawSecret{}
+	createdOperationIndexes := []int{}
+	for index, err := range writer.createSecrets(secretsToCreate) {
+		operation := &secretOperations[createOperationIndexes[index]]
 		if err != nil {
-			return nil, fmt.Errorf("unable to process new secret creations [err=%v]", err)
+			operation.SecretOperation = "SECRET CREATE FAILED"
+			operation.Error = err
+			failedCount++
+			continue
 		}
+		createdSecrets = append(createdSecrets, secretsToCreate[index])
+		createdOperationIndexes = append(createdOperationIndexes, createOperationIndexes[index])
 	}
 
-	for _, secret := range secretsToModify {
-		updateSecretRequest := api.UpdateRawSecretByNameV3Request{
-			SecretName:  secret.SecretKey,
-			SecretValue: secret.SecretValue,
-			SecretPath:  secretsPath,
-			WorkspaceID: projectId,
-			Environment: environmentName,
-			Type:        secret.Type,
+	// a reminder that could not be set does not undo the create, so these are not counted as failed writes
+	reminderFailedCount := 0
+	if options.Metadata.ReminderNote != nil || options.Metadata.ReminderRepeatDays != nil {
+		reminders := make([]api.RawSecret, 0, len(createdSecrets))
+		for _, createdSecret := range createdSecrets {
+			reminders = append(reminders, api.RawSecret{
+				SecretKey:   createdSecret.SecretKey,
+				SecretValue: createdSecret.SecretValue,
+				Type:        createdSecret.Type,
+				Metadata: api.RawSecretMetadata{
+					SecretReminderNote:       options.Metadata.ReminderNote,
+					SecretReminderRepeatDays: options.Metadata.ReminderRepeatDays,
+				},
+			})
 		}
 
-		err = api.CallUpdateRawSecretsV3(httpClient, updateSecretRequest)
+		for index, err := range writer.updateSecrets(reminders) {
+			if err != nil {
+				operation := &secretOperations[createdOperationIndexes[index]]
+				operation.SecretOperation = "SECRET REMINDER FAILED"
+				operation.Error = err
+				reminderFailedCount++
+			}
+		}
+	}
+
+	// in atomic mode there is no point in modifying secrets that would be rolled back right after
+	if options.Atomic && failedCount > 0 {
+		for _, operationIndex := range modifyOperationIndexes {
+			secretOperations[operationIndex].SecretOperation = "SECRET NOT MODIFIED"
+		}
+		secretsToModify = []api.RawSecret{}
+	}
+
+	restorableSecrets := []api.RawSecret{}
+	modifiedOperationIndexes := []int{}
+	for index, err := range writer.updateSecrets(secretsToModify) {
+		operation := &secretOperations[modifyOperationIndexes[index]]
 		if err != nil {
-			return nil, fmt.Errorf("unable to process secret update request [err=%v]", err)
+			operation.SecretOperation = "SECRET MODIFY FAILED"
+			operation.Error = err
+			failedCount++
+			continue
 		}
+		restorableSecrets = append(restorableSecrets, previousSecrets[index])
+		modifiedOperationIndexes = append(modifiedOperationIndexes, modifyOperationIndexes[index])
+	}
+
+	if failedCount == 0 && reminderFailedCount == 0 {
+		return secretOperations, nil
+	}
+
+	if failedCount == 0 {
+		return secretOperations, fmt.Errorf("all secrets were written, but the reminder could not be set on %d of %d created secrets", reminderFailedCount, len(createdSecrets))
 	}
 
-	return secretOperations, nil
+	if !options.Atomic {
+		if reminderFailedCount > 0 {
+			return secretOperations, fmt.Errorf("%d of %d secret writes failed, the other secrets were written but the reminder could not be set on %d of them", failedCount, len(secretsToCreate)+len(modifyOperationIndexes), reminderFailedCount)
+		}
+		return secretOperations, fmt.Errorf("%d of %d secret writes failed, the other secrets were written", failedCount, len(secretsToCreate)+len(modifyOperationIndexes))
+	}
+
+	rollbackFailedCount := 0
+	rollBack := func(operationIndexes []int, errs []error) {
+		for index, err := range errs {
+			operation := &secretOperations[operationIndexes[index]]
+			if err != nil {
+				operation.SecretOperation = "ROLLBACK FAILED"
+				operation.Error = err
+				rollbackFailedCount++
+				continue
+			}
+			operation.SecretOperation = "ROLLED BACK"
+		}
+	}
+	rollBack(createdOperationIndexes, writer.deleteSecrets(createdSecrets))
+	rollBack(modifiedOperationIndexes, writer.updateSecrets(restorableSecrets))
+
+	if rollbackFailedCount > 0 {
+		return secretOperations, fmt.Errorf("%d secret write(s) failed and %d of the written secrets could not be rolled back", failedCount, rollbackFailedCount)
+	}
+
+	return secretOperations, fmt.Errorf("%d secret write(s) failed, the secrets that were written have been rolled back", failedCount)
+}
+
+// getSecretMetadataChanges returns the metadata to write to change the comment, tags and reminder of the secret, and the metadata that
+// restores the comment and tags it had. Fields that would not change are left nil. Reminders are always written when they are set
+func getSecretMetadataChanges(secret models.SingleEnvironmentVariable, changes models.SecretMetadataChanges) (api.RawSecretMetadata, api.RawSecretMetadata) {
+	metadata := api.RawSecretMetadata{
+		SecretReminderNote:       changes.ReminderNote,
+		SecretReminderRepeatDays: changes.ReminderRepeatDays,
+	}
+	previousMetadata := api.RawSecretMetadata{}
+
+	if changes.Comment != nil && *changes.Comment != secret.Comment {
+		previousComment := secret.Comment
+		metadata.SecretComment = changes.Comment
+		previousMetadata.SecretComment = &previousComment
+	}
+
+	if len(changes.AddTagIDs) > 0 || len(changes.RemoveTagIDs) > 0 {
+		previousTagIDs := []string{}
+		tagIDs := []string{}
+		for _, tag := range secret.Tags {
+			previousTagIDs = append(previousTagIDs, tag.ID)
+			if !slices.Contains(changes.RemoveTagIDs, tag.ID) {
+				tagIDs = append(tagIDs, tag.ID)
+			}
+		}
+
+		for _, tagID := range changes.AddTagIDs {
+			if !slices.Contains(tagIDs, tagID) {
+				tagIDs = append(tagIDs, tagID)
+			}
+		}
+
+		if !slices.Equal(tagIDs, previousTagIDs) {
+			metadata.TagIDs = &tagIDs
+			previousMetadata.TagIDs = &previousTagIDs
+		}
+	}
 
+	return metadata, previousMetadata
 }
@@ -0,0 +1,122 @@
+package util
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+)
+
+// PlanSecretsCopy decides what to do with every source secret. Secrets missing from the target are created, secrets with the same value
+// are left unchanged, and secrets with a different value follow the conflict policy. Both sides are matched by relative SecretPath and key
+func PlanSecretsCopy(sourceSecrets []models.SingleEnvironmentVariable, targetSecrets []models.SingleEnvironmentVariable, conflictPolicy string) ([]models.SecretCopyOperation, error) {
+	conflictActions := map[string]string{
+		SECRET_CONFLICT_POLICY_SKIP:      SECRET_COPY_SKIP,
+		SECRET_CONFLICT_POLICY_OVERWRITE: SECRET_COPY_OVERWRITE,
+		SECRET_CONFLICT_POLICY_FAIL:      SECRET_COPY_CONFLICT,
+	}
+	conflictAction, ok := conflictActions[conflictPolicy]
+	if !ok {
+		return nil, fmt.Errorf("invalid conflict policy %q. Available policies are [%s, %s, %s]", conflictPolicy, SECRET_CONFLICT_POLICY_SKIP, SECRET_CONFLICT_POLICY_OVERWRITE, SECRET_CONFLICT_POLICY_FAIL)
+	}
+
+	targetValues := make(map[string]string, len(targetSecrets))
+	for _, secret := range targetSecrets {
+		targetValues[path.Join(secret.SecretPath, secret.Key)] = secret.Value
+	}
+
+	operations := make([]models.SecretCopyOperation, 0, len(sourceSecrets))
+	for _, secret := range sourceSecrets {
+		operation := models.SecretCopyOperation{SecretPath: secret.SecretPath, Key: secret.Key, Value: secret.Value, Action: SECRET_COPY_CREATE}
+
+		if targetValue, exists := targetValues[path.Join(secret.SecretPath, secret.Key)]; exists {
+			if targetValue == secret.Value {
+				operation.Action = SECRET_COPY_UNCHANGED
+			} else {
+				operation.Action = conflictAction
+			}
+		}
+
+		operations = append(operations, operation)
+	}
+
+	sort.SliceStable(operations, func(i, j int) bool {
+		if operations[i].SecretPath != operations[j].SecretPath {
+			return operations[i].SecretPath < operations[j].SecretPath
+		}
+		return operations[i].Key < operations[j].Key
+	})
+
+	return operations, nil
+}
+
+// GetMissingFolders returns the folders of the paths that do not exist yet, parents first, so they can be created in order.
+// Every parent folder is listed at most once
+func GetMissingFolders(params models.GetAllFoldersParameters, folderPaths []string) ([]string, error) {
+	// the names of the sub-folders of each folder listed so far
+	foldersByParent := map[string]map[string]bool{}
+	missingFolders := map[string]bool{}
+
+	var isMissing func(folderPath string) (bool, error)
+	isMissing = func(folderPath string) (bool, error) {
+		if folderPath == "/" {
+			return false, nil
+		}
+
+		parent, name := path.Split(folderPath)
+		parent = path.Clean(parent)
+
+		parentMissing, err := isMissing(parent)
+		if err != nil {
+			return false, err
+		}
+		if parentMissing {
+			missingFolders[folderPath] = true
+			return true, nil
+		}
+
+		subFolders, listed := foldersByParent[parent]
+		if !listed {
+			params.FoldersPath = parent
+			folders, err := GetAllFolders(params)
+			if err != nil {
+				return false, fmt.Errorf("unable to list the folders of [%s] [err=%v]", parent, err)
+			}
+
+			subFolders = map[string]bool{}
+			for _, folder := range folders {
+				subFolders[folder.Name] = true
+			}
+			foldersByParent[parent] = subFolders
+		}
+
+		if !subFolders[name] {
+			missingFolders[folderPath] = true
+			return true, nil
+		}
+		return false, nil
+	}
+
+	for _, folderPath := range folderPaths {
+		if _, err := isMissing(path.Clean("/" + folderPath)); err != nil {
+			return nil, err
+		}
+	}
+
+	sortedMissingFolders := make([]string, 0, len(missingFolders))
+	for folderPath := range missingFolders {
+		sortedMissingFolders = append(sortedMissingFolders, folderPath)
+	}
+	// sorting by depth puts the parents before their children
+	sort.Slice(sortedMissingFolders, func(i, j int) bool {
+		depthI, depthJ := strings.Count(sortedMissingFolders[i], "/"), strings.Count(sortedMissingFolders[j], "/")
+		if depthI != depthJ {
+			return depthI < depthJ
+		}
+		return sortedMissingFolders[i] < sortedMissingFolders[j]
+	})
+
+	return sortedMissingFolders, nil
+}
@@ -0,0 +1,110 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/config"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSecretsCopy(t *testing.T) {
+	sourceSecrets := []models.SingleEnvironmentVariable{
+		{Key: "API_URL", Value: "https://api", SecretPath: "/"},
+		{Key: "DB_HOST", Value: "db.staging", SecretPath: "/"},
+		{Key: "LOG_LEVEL", Value: "debug", SecretPath: "/"},
+		{Key: "DB_HOST", Value: "db.staging", SecretPath: "/worker"},
+	}
+	targetSecrets := []models.SingleEnvironmentVariable{
+		{Key: "API_URL", Value: "https://api", SecretPath: "/"},
+		{Key: "DB_HOST", Value: "db.prod", SecretPath: "/"},
+	}
+
+	tests := []struct {
+		policy       string
+		dbHostAction string
+	}{
+		{policy: SECRET_CONFLICT_POLICY_FAIL, dbHostAction: SECRET_COPY_CONFLICT},
+		{policy: SECRET_CONFLICT_POLICY_SKIP, dbHostAction: SECRET_COPY_SKIP},
+		{policy: SECRET_CONFLICT_POLICY_OVERWRITE, dbHostAction: SECRET_COPY_OVERWRITE},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			operations, err := PlanSecretsCopy(sourceSecrets, targetSecrets, test.policy)
+			assert.NoError(t, err)
+			assert.Equal(t, []models.SecretCopyOperation{
+				{SecretPath: "/", Key: "API_URL", Value: "https://api", Action: SECRET_COPY_UNCHANGED},
+				{SecretPath: "/", Key: "DB_HOST", Value: "db.staging", Action: test.dbHostAction},
+				{SecretPath: "/", Key: "LOG_LEVEL", Value: "debug", Action: SECRET_COPY_CREATE},
+				{SecretPath: "/worker", Key: "DB_HOST", Value: "db.staging", Action: SECRET_COPY_CREATE},
+			}, operations)
+		})
+	}
+
+	_, err := PlanSecretsCopy(sourceSecrets, targetSecrets, "merge")
+	assert.ErrorContains(t, err, "invalid conflict policy")
+}
+
+func TestGetMissingFolders(t *testing.T) {
+	subFoldersByPath := map[string][]string{
+		"/":       {"api"},
+		"/api":    {"v1"},
+		"/api/v1": {},
+	}
+
+	listedPaths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		directory := r.URL.Query().Get("directory")
+		listedPaths = append(listedPaths, directory)
+
+		response := api.GetFoldersV1Response{}
+		for _, name := range subFoldersByPath[directory] {
+			response.Folders = append(response.Folders, struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			}{ID: name, Name: name})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	previousUrl := config.INFISICAL_URL
+	config.INFISICAL_URL = server.URL
+	t.Cleanup(func() { config.INFISICAL_URL = previousUrl })
+
+	params := models.GetAllFoldersParameters{WorkspaceId: "project", Environment: "prod", UniversalAuthAccessToken: "token"}
+
+	missingFolders, err := GetMissingFolders(params, []string{"/api/v1/users/admin", "/api", "api/v1", "/worker/jobs", "/"})
+	assert.NoError(t, err)
+	// parents come before their children, so the folders can be created in order
+	assert.Equal(t, []string{"/worker", "/worker/jobs", "/api/v1/users", "/api/v1/users/admin"}, missingFolders)
+	// each folder is listed once, and the children of missing folders are not listed at all
+	assert.ElementsMatch(t, []string{"/", "/api", "/api/v1"}, listedPaths)
+
+	listedPaths = []string{}
+	missingFolders, err = GetMissingFolders(params, []string{"/api", "/api/v1"})
+	assert.NoError(t, err)
+	assert.Empty(t, missingFolders)
+	assert.ElementsMatch(t, []string{"/", "/api"}, listedPaths)
+}
+
+func TestGetMissingFoldersListError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	t.Cleanup(server.Close)
+
+	previousUrl := config.INFISICAL_URL
+	config.INFISICAL_URL = server.URL
+	t.Cleanup(func() { config.INFISICAL_URL = previousUrl })
+
+	_, err := GetMissingFolders(models.GetAllFoldersParameters{WorkspaceId: "project", Environment: "prod", UniversalAuthAccessToken: "token"}, []string{"/api"})
+	assert.ErrorContains(t, err, "unable to list the folders of [/]")
+}
@@ -0,0 +1,174 @@
+package util
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	SECRETS_FILE_FORMAT_DOTENV = "dotenv"
+	SECRETS_FILE_FORMAT_JSON   = "json"
+	SECRETS_FILE_FORMAT_YAML   = "yaml"
+)
+
+// GetSecretsFileFormat returns the format of a secrets file from its extension. Files without a known extension, and stdin, are read as dotenv
+func GetSecretsFileFormat(filePath string) string {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json":
+		return SECRETS_FILE_FORMAT_JSON
+	case ".yaml", ".yml":
+		return SECRETS_FILE_FORMAT_YAML
+	default:
+		return SECRETS_FILE_FORMAT_DOTENV
+	}
+}
+
+// ParseSecretsFile reads the secrets of a dotenv, JSON or YAML file. JSON files can be an object of keys and values, or the array written by
+// `infisical export --format=json`. YAML files are a mapping of keys and values. Numbers and booleans are read as they are written in the file
+func ParseSecretsFile(content string, format string) ([]models.SingleEnvironmentVariable, error) {
+	switch format {
+	case SECRETS_FILE_FORMAT_DOTENV:
+		return ParseDotEnv(content)
+	case SECRETS_FILE_FORMAT_JSON:
+		return parseJsonSecretsFile(content)
+	case SECRETS_FILE_FORMAT_YAML:
+		return parseYamlSecretsFile(content)
+	default:
+		return nil, fmt.Errorf("invalid file format %q. Available file formats are [%s, %s, %s]", format, SECRETS_FILE_FORMAT_DOTENV, SECRETS_FILE_FORMAT_JSON, SECRETS_FILE_FORMAT_YAML)
+	}
+}
+
+func parseJsonSecretsFile(content string) ([]models.SingleEnvironmentVariable, error) {
+	if strings.HasPrefix(strings.TrimSpace(content), "[") {
+		var exportedSecrets []models.SingleEnvironmentVariable
+		if err := json.Unmarshal([]byte(content), &exportedSecrets); err != nil {
+			return nil, fmt.Errorf("invalid json file [err=%v]", err)
+		}
+
+		secrets := make([]models.SingleEnvironmentVariable, 0, len(exportedSecrets))
+		for _, secret := range exportedSecrets {
+			secrets = append(secrets, models.SingleEnvironmentVariable{Key: secret.Key, Value: secret.Value, Type: SECRET_TYPE_SHARED})
+		}
+		return secrets, nil
+	}
+
+	// numbers are kept as written, so large integers and decimals are not rounded through a float64
+	decoder := json.NewDecoder(strings.NewReader(content))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return nil, fmt.Errorf("invalid json file [err=%v]", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("invalid json file [err=unexpected data after the top-level object]")
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	secrets := make([]models.SingleEnvironmentVariable, 0, len(values))
+	for _, key := range keys {
+		value, err := getSecretsFileValue(key, values[key])
+		if err != nil {
+			return nil, err
+		}
+		secrets = append(secrets, models.SingleEnvironmentVariable{Key: key, Value: value, Type: SECRET_TYPE_SHARED})
+	}
+	return secrets, nil
+}
+
+func parseYamlSecretsFile(content string) ([]models.SingleEnvironmentVariable, error) {
+	// the nodes keep the secrets in the order of the file and the values as they are written, e.g. 0755 or 1.50
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &document); err != nil {
+		return nil, fmt.Errorf("invalid yaml file [err=%v]", err)
+	}
+
+	// an empty file has no document
+	if len(document.Content) == 0 {
+		return []models.SingleEnvironmentVariable{}, nil
+	}
+
+	mapping := document.Content[0]
+	if mapping.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("invalid yaml file [err=the file must be a mapping of keys and values]")
+	}
+
+	secrets := make([]models.SingleEnvironmentVariable, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i].Value
+		valueNode := mapping.Content[i+1]
+		if valueNode.Kind == yaml.AliasNode {
+			valueNode = valueNode.Alias
+		}
+
+		if valueNode.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("the value of [%s] is not a string. Nested values cannot be set as secrets", key)
+		}
+
+		value := valueNode.Value
+		if valueNode.ShortTag() == "!!null" {
+			value = ""
+		}
+		secrets = append(secrets, models.SingleEnvironmentVariable{Key: key, Value: value, Type: SECRET_TYPE_SHARED})
+	}
+	return secrets, nil
+}
+
+func getSecretsFileValue(key string, value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return fmt.Sprint(v), nil
+	default:
+		return "", fmt.Errorf("the value of [%s] is not a string. Nested values cannot be set as secrets", key)
+	}
+}
+
+// ParseSecretArgs parses KEY=VALUE arguments of `infisical secrets set`
+func ParseSecretArgs(secretArgs []string) ([]models.SingleEnvironmentVariable, error) {
+	secrets := make([]models.SingleEnvironmentVariable, 0, len(secretArgs))
+	for _, arg := range secretArgs {
+		key, value, found := strings.Cut(arg, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid secret %q. Secrets must be in the form KEY=VALUE", arg)
+		}
+		secrets = append(secrets, models.SingleEnvironmentVariable{Key: key, Value: value, Type: SECRET_TYPE_SHARED})
+	}
+	return secrets, nil
+}
+
+// ValidateSecretsToSet checks the keys and values of secrets before they are set. Empty values are only accepted with allowEmpty
+func ValidateSecretsToSet(secrets []models.SingleEnvironmentVariable, allowEmpty bool) error {
+	for _, secret := range secrets {
+		if secret.Key == "" {
+			return fmt.Errorf("ensure that each secret has a non empty key. Modify the input and try again")
+		}
+
+		if unicode.IsNumber(rune(secret.Key[0])) {
+			return fmt.Errorf("keys of secrets cannot start with a number, [%s] does. Modify the key name(s) and try again", secret.Key)
+		}
+
+		if secret.Value == "" && !allowEmpty {
+			return fmt.Errorf("the value of [%s] is empty. Use --allow-empty to set secrets with empty values", secret.Key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSecretsFile(t *testing.T) {
+	expected := []models.SingleEnvironmentVariable{
+		{Key: "DB_HOST", Value: "localhost", Type: SECRET_TYPE_SHARED},
+		{Key: "DB_PORT", Value: "5432", Type: SECRET_TYPE_SHARED},
+	}
+
+	testCases := []struct {
+		name    string
+		format  string
+		content string
+	}{
+		{name: "json object", format: SECRETS_FILE_FORMAT_JSON, content: `{"DB_PORT": 5432, "DB_HOST": "localhost"}`},
+		{name: "json export", format: SECRETS_FILE_FORMAT_JSON, content: `[{"key": "DB_HOST", "value": "localhost"}, {"key": "DB_PORT", "value": "5432"}]`},
+		{name: "yaml", format: SECRETS_FILE_FORMAT_YAML, content: "DB_HOST: localhost\nDB_PORT: 5432\n"},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			secrets, err := ParseSecretsFile(testCase.content, testCase.format)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, secrets)
+		})
+	}
+
+	_, err := ParseSecretsFile("DB:\n  HOST: localhost\n", SECRETS_FILE_FORMAT_YAML)
+	assert.ErrorContains(t, err, "the value of [DB] is not a string")
+}
+
+func TestGetSecretsFileFormat(t *testing.T) {
+	assert.Equal(t, SECRETS_FILE_FORMAT_JSON, GetSecretsFileFormat("secrets.JSON"))
+	assert.Equal(t, SECRETS_FILE_FORMAT_YAML, GetSecretsFileFormat("config/secrets.yml"))
+	assert.Equal(t, SECRETS_FILE_FORMAT_DOTENV, GetSecretsFileFormat(".env.local"))
+	assert.Equal(t, SECRETS_FILE_FORMAT_DOTENV, GetSecretsFileFormat("-"))
+}
+
+func TestValidateSecretsToSet(t *testing.T) {
+	secrets, err := ParseSecretArgs([]string{"API_KEY=abc=", "EMPTY="})
+	assert.NoError(t, err)
+	assert.Equal(t, "abc=", secrets[0].Value)
+
+	assert.ErrorContains(t, ValidateSecretsToSet(secrets, false), "the value of [EMPTY] is empty")
+	assert.NoError(t, ValidateSecretsToSet(secrets, true))
+	assert.ErrorContains(t, ValidateSecretsToSet([]models.SingleEnvironmentVariable{{Key: "1KEY", Value: "x"}}, true), "cannot start with a number")
+
+	_, err = ParseSecretArgs([]string{"API_KEY"})
+	assert.ErrorContains(t, err, "KEY=VALUE")
+}
+
+func TestParseSecretsFileKeepsScalarsAsWritten(t *testing.T) {
+	expected := []models.SingleEnvironmentVariable{
+		{Key: "MODE", Value: "0755", Type: SECRET_TYPE_SHARED},
+		{Key: "RATIO", Value: "1.50", Type: SECRET_TYPE_SHARED},
+		{Key: "ENABLED", Value: "true", Type: SECRET_TYPE_SHARED},
+		{Key: "ACCOUNT_ID", Value: "123456789012345678901", Type: SECRET_TYPE_SHARED},
+		{Key: "EMPTY", Value: "", Type: SECRET_TYPE_SHARED},
+	}
+
+	secrets, err := ParseSecretsFile("MODE: 0755\nRATIO: 1.50\nENABLED: true\nACCOUNT_ID: 123456789012345678901\nEMPTY:\n", SECRETS_FILE_FORMAT_YAML)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, secrets)
+
+	secrets, err = ParseSecretsFile("ENABLED: yes\nMODE: 0o755\nRATIO: 1e3\n", SECRETS_FILE_FORMAT_YAML)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.SingleEnvironmentVariable{
+		{Key: "ENABLED", Value: "yes", Type: SECRET_TYPE_SHARED},
+		{Key: "MODE", Value: "0o755", Type: SECRET_TYPE_SHARED},
+		{Key: "RATIO", Value: "1e3", Type: SECRET_TYPE_SHARED},
+	}, secrets)
+
+	// JSON has no octal numbers, so the mode is written as a string
+	secrets, err = ParseSecretsFile(`{"MODE": "0755", "RATIO": 1.50, "ENABLED": true, "ACCOUNT_ID": 123456789012345678901, "EMPTY": null}`, SECRETS_FILE_FORMAT_JSON)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, expected, secrets)
+
+	_, err = ParseSecretsFile(`{"DB": {"HOST": "localhost"}}`, SECRETS_FILE_FORMAT_JSON)
+	assert.ErrorContains(t, err, "the value of [DB] is not a string")
+
+	_, err = ParseSecretsFile("HOSTS:\n  - a\n  - b\n", SECRETS_FILE_FORMAT_YAML)
+	assert.ErrorContains(t, err, "the value of [HOSTS] is not a string")
+}
@@ -0,0 +1,253 @@
+package util
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+	"github.com/Infisical/infisical-merge/packages/config"
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformSecretKeys(t *testing.T) {
+	secrets := []models.SingleEnvironmentVariable{
+		{Key: "APP_DB_HOST", Value: "localhost"},
+		{Key: "APP_DB_PASSWORD", Value: "password"},
+		{Key: "APP_api.key", Value: "key"},
+		{Key: "DATABASE_URL", Value: "postgres://localhost"},
+		{Key: "INTERNAL_TOKEN", Value: "token"},
+	}
+
+	tests := []struct {
+		name     string
+		options  models.SecretKeyTransformOptions
+		expected []string
+	}{
+		{
+			name:     "No options",
+			options:  models.SecretKeyTransformOptions{},
+			expected: []string{"APP_DB_HOST", "APP_DB_PASSWORD", "APP_api.key", "DATABASE_URL", "INTERNAL_TOKEN"},
+		},
+		{
+			name: "Include and exclude",
+			options: models.SecretKeyTransformOptions{
+				Include: []string{"APP_*", "DATABASE_URL"},
+				Exclude: []string{"*PASSWORD"},
+			},
+			expected: []string{"APP_DB_HOST", "APP_api.key", "DATABASE_URL"},
+		},
+		{
+			name: "Strip prefix, prefix, sanitise and map",
+			options: models.SecretKeyTransformOptions{
+				Exclude:      []string{"INTERNAL_*"},
+				StripPrefix:  "APP_",
+				Prefix:       "SVC_",
+				KeyMappings:  map[string]string{"DATABASE_URL": "DB_URL"},
+				SanitizeKeys: true,
+			},
+			expected: []string{"SVC_DB_HOST", "SVC_DB_PASSWORD", "SVC_API_KEY", "DB_URL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transformedSecrets, originalKeyByKey, err := TransformSecretKeys(secrets, tt.options)
+			assert.NoError(t, err)
+
+			keys := []string{}
+			for _, secret := range transformedSecrets {
+				keys = append(keys, secret.Key)
+				assert.Contains(t, originalKeyByKey, secret.Key)
+			}
+			assert.Equal(t, tt.expected, keys)
+		})
+	}
+}
+
+func TestTransformSecretKeysInvalidPattern(t *testing.T) {
+	_, _, err := TransformSecretKeys([]models.SingleEnvironmentVariable{{Key: "KEY"}}, models.SecretKeyTransformOptions{Include: []string{"["}})
+	assert.Error(t, err)
+}
+
+func TestSanitizeEnvVarName(t *testing.T) {
+	assert.Equal(t, "DB_HOST", SanitizeEnvVarName("db.host"))
+	assert.Equal(t, "API_KEY_V2", SanitizeEnvVarName("api-key--v2"))
+	assert.Equal(t, "_1PASSWORD", SanitizeEnvVarName("1password"))
+}
+
+func TestFilterSecretsDiff(t *testing.T) {
+	diff := models.SecretsDiff{
+		Added:    []string{"DB_PORT", "NOISY_COUNTER"},
+		Removed:  []string{"API_KEY"},
+		Modified: []string{"DB_HOST", "NOISY_TIMESTAMP"},
+	}
+
+	watchedDiff, err := FilterSecretsDiff(diff, []string{"DB_*", "API_*"}, []string{"DB_PORT"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"API_KEY", "DB_HOST"}, SecretsDiffKeys(watchedDiff))
+
+	watchedDiff, err = FilterSecretsDiff(diff, nil, []string{"NOISY_*"})
+	assert.NoError(t, err)
+	assert.Equal(t, "added [DB_PORT], removed [API_KEY], modified [DB_HOST]", DescribeSecretsDiff(watchedDiff))
+
+	watchedDiff, err = FilterSecretsDiff(diff, []string{"UNUSED_*"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, SecretsDiffKeys(watchedDiff))
+	assert.Equal(t, "no secret values changed", DescribeSecretsDiff(watchedDiff))
+}
+
+func TestGetRelativeSecretPath(t *testing.T) {
+	assert.Equal(t, "/db", GetRelativeSecretPath("/", "/db"))
+	assert.Equal(t, "/", GetRelativeSecretPath("/app", "/app"))
+	assert.Equal(t, "/db/replica", GetRelativeSecretPath("/app/", "/app/db/replica"))
+	assert.Equal(t, "/", GetRelativeSecretPath("/app", "/apple"))
+	assert.Equal(t, "/", GetRelativeSecretPath("/app", ""))
+}
+
+func TestCompareSecrets(t *testing.T) {
+	fromSecrets := []models.SingleEnvironmentVariable{
+		{Key: "DB_HOST", Value: "localhost"},
+		{Key: "DEBUG", Value: "true"},
+		{Key: "API_KEY", Value: "key"},
+	}
+	toSecrets := []models.SingleEnvironmentVariable{
+		{Key: "API_KEY", Value: "key"},
+		{Key: "DB_HOST", Value: "db.internal"},
+		{Key: "SENTRY_DSN", Value: "dsn"},
+	}
+
+	assert.Equal(t, []models.SecretComparison{
+		{Key: "API_KEY", Status: SECRET_COMPARISON_IDENTICAL, FromValue: "key", ToValue: "key"},
+		{Key: "DB_HOST", Status: SECRET_COMPARISON_DIFFERENT, FromValue: "localhost", ToValue: "db.internal"},
+		{Key: "DEBUG", Status: SECRET_COMPARISON_MISSING, FromValue: "true"},
+		{Key: "SENTRY_DSN", Status: SECRET_COMPARISON_EXTRA, ToValue: "dsn"},
+	}, CompareSecrets(fromSecrets, toSecrets))
+}
+
+func TestGetSecretMetadataChanges(t *testing.T) {
+	secret := rawSecretToEnvironmentVariable(api.RawSecretV3{
+		SecretKey:     "DB_PASSWORD",
+		SecretComment: "rotated monthly",
+		Tags:          []api.SecretTagV3{{ID: "tag-db", Slug: "db"}, {ID: "tag-legacy", Slug: "legacy"}},
+	})
+
+	comment := "rotated monthly"
+	metadata, previousMetadata := getSecretMetadataChanges(secret, models.SecretMetadataChanges{Comment: &comment, AddTagIDs: []string{"tag-db"}})
+	assert.Equal(t, api.RawSecretMetadata{}, metadata)
+	assert.Equal(t, api.RawSecretMetadata{}, previousMetadata)
+
+	comment = "rotated weekly"
+	metadata, previousMetadata = getSecretMetadataChanges(secret, models.SecretMetadataChanges{
+		Comment:      &comment,
+		AddTagIDs:    []string{"tag-prod"},
+		RemoveTagIDs: []string{"tag-legacy"},
+	})
+	assert.Equal(t, "rotated weekly", *metadata.SecretComment)
+	assert.Equal(t, []string{"tag-db", "tag-prod"}, *metadata.TagIDs)
+	assert.Equal(t, "rotated monthly", *previousMetadata.SecretComment)
+	assert.Equal(t, []string{"tag-db", "tag-legacy"}, *previousMetadata.TagIDs)
+
+	metadata, _ = getSecretMetadataChanges(secret, models.SecretMetadataChanges{RemoveTagIDs: []string{"tag-db", "tag-legacy"}})
+	assert.Equal(t, []string{}, *metadata.TagIDs)
+
+	repeatDays := 30
+	metadata, previousMetadata = getSecretMetadataChanges(secret, models.SecretMetadataChanges{ReminderRepeatDays: &repeatDays})
+	assert.Equal(t, 30, *metadata.SecretReminderRepeatDays)
+	assert.Equal(t, api.RawSecretMetadata{}, previousMetadata)
+}
+
+func TestGetTagIDsBySlugs(t *testing.T) {
+	tags := []api.ProjectTag{{ID: "tag-db", Slug: "db"}, {ID: "tag-prod", Slug: "prod"}}
+
+	tagIDs, err := GetTagIDsBySlugs(tags, []string{"prod", "db"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tag-prod", "tag-db"}, tagIDs)
+
+	_, err = GetTagIDsBySlugs(tags, []string{"staging"})
+	assert.ErrorContains(t, err, "[staging]")
+}
+
+func TestParseSecretSource(t *testing.T) {
+	defaults := models.GetAllSecretsParameters{WorkspaceId: "default-project", Environment: "dev", SecretsPath: "/", UniversalAuthAccessToken: "token"}
+
+	tests := []struct {
+		source   string
+		expected models.GetAllSecretsParameters
+	}{
+		{"::", defaults},
+		{"project:prod:/api", models.GetAllSecretsParameters{WorkspaceId: "project", Environment: "prod", SecretsPath: "/api", UniversalAuthAccessToken: "token"}},
+		{":staging:", models.GetAllSecretsParameters{WorkspaceId: "default-project", Environment: "staging", SecretsPath: "/", UniversalAuthAccessToken: "token"}},
+		{"::/shared:recursive", models.GetAllSecretsParameters{WorkspaceId: "default-project", Environment: "dev", SecretsPath: "/shared", Recursive: true, UniversalAuthAccessToken: "token"}},
+		{"::/shared:false", models.GetAllSecretsParameters{WorkspaceId: "default-project", Environment: "dev", SecretsPath: "/shared", UniversalAuthAccessToken: "token"}},
+	}
+
+	for _, test := range tests {
+		params, err := ParseSecretSource(test.source, defaults)
+		assert.NoError(t, err, test.source)
+		assert.Equal(t, test.expected, params, test.source)
+	}
+
+	for _, source := range []string{"", "prod", "project:prod", "project:prod:/api:recursive:extra", "project:prod:/api:deep"} {
+		_, err := ParseSecretSource(source, defaults)
+		assert.Error(t, err, source)
+	}
+}
+
+func TestGetAllEnvironmentVariablesFromSources(t *testing.T) {
+	secretsByFolder := map[string][]api.RawSecretV3{
+		"dev:/": {
+			{SecretKey: "DB_HOST", SecretValue: "localhost", Type: SECRET_TYPE_SHARED},
+			{SecretKey: "LOG_LEVEL", SecretValue: "debug", Type: SECRET_TYPE_SHARED},
+		},
+		"dev:/api": {
+			{SecretKey: "DB_HOST", SecretValue: "db.internal", Type: SECRET_TYPE_SHARED},
+			{SecretKey: "API_KEY", SecretValue: "shared-key", Type: SECRET_TYPE_SHARED},
+			{SecretKey: "API_KEY", SecretValue: "personal-key", Type: SECRET_TYPE_PERSONAL},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		folder := r.URL.Query().Get("environment") + ":" + r.URL.Query().Get("secretPath")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(api.GetRawSecretsV3Response{Secrets: secretsByFolder[folder], Imports: []api.ImportedRawSecretV3{}})
+	}))
+	t.Cleanup(server.Close)
+
+	previousUrl := config.INFISICAL_URL
+	config.INFISICAL_URL = server.URL
+	t.Cleanup(func() { config.INFISICAL_URL = previousUrl })
+
+	sources := []models.GetAllSecretsParameters{
+		{WorkspaceId: "project", Environment: "dev", SecretsPath: "/", UniversalAuthAccessToken: "token"},
+		{WorkspaceId: "project", Environment: "dev", SecretsPath: "/api", UniversalAuthAccessToken: "token"},
+	}
+
+	result, err := GetAllEnvironmentVariablesFromSources(sources, "", true)
+	assert.NoError(t, err)
+
+	values := map[string]string{}
+	for _, secret := range result.Secrets {
+		values[secret.Key] = secret.Value
+	}
+
+	// the later source wins, and personal secrets override shared ones within a source
+	assert.Equal(t, map[string]string{"DB_HOST": "db.internal", "LOG_LEVEL": "debug", "API_KEY": "personal-key"}, values)
+	assert.Equal(t, map[string]string{
+		"DB_HOST":   "project:dev:/api",
+		"LOG_LEVEL": "project:dev:/",
+		"API_KEY":   "project:dev:/api",
+	}, result.SourceByKey)
+
+	// reversing the sources reverses the precedence
+	result, err = GetAllEnvironmentVariablesFromSources([]models.GetAllSecretsParameters{sources[1], sources[0]}, "", false)
+	assert.NoError(t, err)
+
+	values = map[string]string{}
+	for _, secret := range result.Secrets {
+		values[secret.Key] = secret.Value
+	}
+	assert.Equal(t, map[string]string{"DB_HOST": "localhost", "LOG_LEVEL": "debug", "API_KEY": "shared-key"}, values)
+}
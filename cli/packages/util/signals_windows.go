@@ -0,0 +1,15 @@
+//go:build windows
+
+package util
+
+import "syscall"
+
+// signals that can be referenced by name in CLI flags such as --stop-signal.
+// Windows can only deliver a handful of these, so the list is intentionally short
+var signalsByName = map[string]syscall.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGKILL": syscall.SIGKILL,
+	"SIGTERM": syscall.SIGTERM,
+}
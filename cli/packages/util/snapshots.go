@@ -0,0 +1,62 @@
+package util
+
+import (
+	"sort"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+)
+
+// PlanSnapshotRestore lists what restoring a snapshot would change in its folder: the secrets that would be created, updated
+// or deleted, then the sub-folders that would be created or deleted. The content of the sub-folders is restored as well, but
+// is not compared
+func PlanSnapshotRestore(currentSecrets []models.SingleEnvironmentVariable, snapshotSecrets []models.SingleEnvironmentVariable, currentFolders []string, snapshotFolders []string) []models.SnapshotChange {
+	changes := []models.SnapshotChange{}
+	for _, comparison := range CompareSecrets(currentSecrets, snapshotSecrets) {
+		change := models.SnapshotChange{
+			Kind:          SNAPSHOT_CHANGE_KIND_SECRET,
+			Name:          comparison.Key,
+			CurrentValue:  comparison.FromValue,
+			SnapshotValue: comparison.ToValue,
+		}
+
+		switch comparison.Status {
+		case SECRET_COMPARISON_MISSING:
+			change.Action = SNAPSHOT_CHANGE_DELETE
+		case SECRET_COMPARISON_EXTRA:
+			change.Action = SNAPSHOT_CHANGE_CREATE
+		case SECRET_COMPARISON_DIFFERENT:
+			change.Action = SNAPSHOT_CHANGE_UPDATE
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	isCurrentFolder := map[string]bool{}
+	for _, folder := range currentFolders {
+		isCurrentFolder[folder] = true
+	}
+
+	isSnapshotFolder := map[string]bool{}
+	for _, folder := range snapshotFolders {
+		isSnapshotFolder[folder] = true
+	}
+
+	folderChanges := []models.SnapshotChange{}
+	for folder := range isSnapshotFolder {
+		if !isCurrentFolder[folder] {
+			folderChanges = append(folderChanges, models.SnapshotChange{Kind: SNAPSHOT_CHANGE_KIND_FOLDER, Name: folder, Action: SNAPSHOT_CHANGE_CREATE})
+		}
+	}
+	for folder := range isCurrentFolder {
+		if !isSnapshotFolder[folder] {
+			folderChanges = append(folderChanges, models.SnapshotChange{Kind: SNAPSHOT_CHANGE_KIND_FOLDER, Name: folder, Action: SNAPSHOT_CHANGE_DELETE})
+		}
+	}
+
+	sort.Slice(folderChanges, func(i, j int) bool {
+		return folderChanges[i].Name < folderChanges[j].Name
+	})
+
+	return append(changes, folderChanges...)
+}
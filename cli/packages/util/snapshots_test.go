@@ -0,0 +1,31 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/Infisical/infisical-merge/packages/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanSnapshotRestore(t *testing.T) {
+	currentSecrets := []models.SingleEnvironmentVariable{
+		{Key: "ADDED_SINCE", Value: "new"},
+		{Key: "CHANGED", Value: "current"},
+		{Key: "SAME", Value: "value"},
+	}
+	snapshotSecrets := []models.SingleEnvironmentVariable{
+		{Key: "CHANGED", Value: "snapshot"},
+		{Key: "REMOVED_SINCE", Value: "old"},
+		{Key: "SAME", Value: "value"},
+	}
+
+	changes := PlanSnapshotRestore(currentSecrets, snapshotSecrets, []string{"api", "worker"}, []string{"api", "legacy"})
+
+	assert.Equal(t, []models.SnapshotChange{
+		{Kind: SNAPSHOT_CHANGE_KIND_SECRET, Name: "ADDED_SINCE", Action: SNAPSHOT_CHANGE_DELETE, CurrentValue: "new"},
+		{Kind: SNAPSHOT_CHANGE_KIND_SECRET, Name: "CHANGED", Action: SNAPSHOT_CHANGE_UPDATE, CurrentValue: "current", SnapshotValue: "snapshot"},
+		{Kind: SNAPSHOT_CHANGE_KIND_SECRET, Name: "REMOVED_SINCE", Action: SNAPSHOT_CHANGE_CREATE, SnapshotValue: "old"},
+		{Kind: SNAPSHOT_CHANGE_KIND_FOLDER, Name: "legacy", Action: SNAPSHOT_CHANGE_CREATE},
+		{Kind: SNAPSHOT_CHANGE_KIND_FOLDER, Name: "worker", Action: SNAPSHOT_CHANGE_DELETE},
+	}, changes)
+}
@@ -0,0 +1,18 @@
+//go:build linux
+
+package util
+
+import (
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+const prSetChildSubreaper = 36
+
+// setChildSubreaper makes orphaned descendants re-parent to the CLI instead of PID 1, so they are reaped even when the CLI is not PID 1
+func setChildSubreaper() {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		log.Debug().Err(errno).Msg("unable to register the CLI as child subreaper")
+	}
+}
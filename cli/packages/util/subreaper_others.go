@@ -0,0 +1,6 @@
+//go:build !linux && !windows
+
+package util
+
+// setChildSubreaper is a no-op outside of Linux, orphaned descendants are only re-parented to the CLI when it is PID 1
+func setChildSubreaper() {}
@@ -0,0 +1,26 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/Infisical/infisical-merge/packages/api"
+)
+
+// GetTagIDsBySlugs returns the IDs of the tags with the given slugs, in the same order
+func GetTagIDsBySlugs(tags []api.ProjectTag, slugs []string) ([]string, error) {
+	tagIDsBySlug := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		tagIDsBySlug[tag.Slug] = tag.ID
+	}
+
+	tagIDs := make([]string, 0, len(slugs))
+	for _, slug := range slugs {
+		tagID, ok := tagIDsBySlug[slug]
+		if !ok {
+			return nil, fmt.Errorf("the project has no tag [%s], you can create it with [infisical tags create %s]", slug, slug)
+		}
+		tagIDs = append(tagIDs, tagID)
+	}
+
+	return tagIDs, nil
+}
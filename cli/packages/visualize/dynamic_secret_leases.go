@@ -1,6 +1,56 @@
 package visualize
 
-import infisicalModels "github.com/infisical/go-sdk/packages/models"
+import (
+	"fmt"
+	"time"
+
+	infisicalModels "github.com/infisical/go-sdk/packages/models"
+)
+
+const dynamicSecretTimeFormat = "02-Jan-2006 03:04:05 PM"
+
+// DynamicSecretOutput is the JSON and YAML schema of a dynamic secret
+type DynamicSecretOutput struct {
+	Name       string `json:"name" yaml:"name"`
+	Provider   string `json:"provider" yaml:"provider"`
+	DefaultTTL string `json:"defaultTTL" yaml:"defaultTTL"`
+	MaxTTL     string `json:"maxTTL" yaml:"maxTTL"`
+}
+
+// DynamicSecretLeaseOutput is the JSON and YAML schema of a dynamic secret lease
+type DynamicSecretLeaseOutput struct {
+	ID        string    `json:"id" yaml:"id"`
+	ExpireAt  time.Time `json:"expireAt" yaml:"expireAt"`
+	CreatedAt time.Time `json:"createdAt" yaml:"createdAt"`
+}
+
+// DynamicSecretLeaseCredentialsOutput is the JSON and YAML schema of a new lease, with the credentials it was issued
+type DynamicSecretLeaseCredentialsOutput struct {
+	Name        string         `json:"name" yaml:"name"`
+	Provider    string         `json:"provider" yaml:"provider"`
+	LeaseID     string         `json:"leaseId" yaml:"leaseId"`
+	ExpireAt    time.Time      `json:"expireAt" yaml:"expireAt"`
+	Credentials map[string]any `json:"credentials" yaml:"credentials"`
+}
+
+func PrintDynamicSecretLeaseCredentials(dynamicSecret infisicalModels.DynamicSecret, lease infisicalModels.DynamicSecretLease, leaseCredentials map[string]any) {
+	output := DynamicSecretLeaseCredentialsOutput{
+		Name:        dynamicSecret.Name,
+		Provider:    dynamicSecret.Type,
+		LeaseID:     lease.Id,
+		ExpireAt:    lease.ExpireAt,
+		Credentials: leaseCredentials,
+	}
+
+	Render(output, func() {
+		fmt.Println("Dynamic Secret Leasing")
+		fmt.Printf("Name: %s\n", dynamicSecret.Name)
+		fmt.Printf("Provider: %s\n", dynamicSecret.Type)
+		fmt.Printf("Lease ID: %s\n", lease.Id)
+		fmt.Printf("Expire At: %s\n", lease.ExpireAt.Local().Format(dynamicSecretTimeFormat))
+		PrintAllDyamicSecretLeaseCredentials(leaseCredentials)
+	})
+}
 
 func PrintAllDyamicSecretLeaseCredentials(leaseCredentials map[string]any) {
 	rows := [][]string{}
@@ -16,24 +66,27 @@ func PrintAllDyamicSecretLeaseCredentials(leaseCredentials map[string]any) {
 }
 
 func PrintAllDynamicRootCredentials(dynamicRootCredentials []infisicalModels.DynamicSecret) {
+	output := []DynamicSecretOutput{}
 	rows := [][]string{}
 	for _, el := range dynamicRootCredentials {
+		output = append(output, DynamicSecretOutput{Name: el.Name, Provider: el.Type, DefaultTTL: el.DefaultTTL, MaxTTL: el.MaxTTL})
 		rows = append(rows, []string{el.Name, el.Type, el.DefaultTTL, el.MaxTTL})
 	}
 
 	headers := []string{"Name", "Provider", "Default TTL", "Max TTL"}
 
-	GenericTable(headers, rows)
+	Render(output, func() { GenericTable(headers, rows) })
 }
 
 func PrintAllDynamicSecretLeases(dynamicSecretLeases []infisicalModels.DynamicSecretLease) {
+	output := []DynamicSecretLeaseOutput{}
 	rows := [][]string{}
-	const timeformat = "02-Jan-2006 03:04:05 PM"
 	for _, el := range dynamicSecretLeases {
-		rows = append(rows, []string{el.Id, el.ExpireAt.Local().Format(timeformat), el.CreatedAt.Local().Format(timeformat)})
+		output = append(output, DynamicSecretLeaseOutput{ID: el.Id, ExpireAt: el.ExpireAt, CreatedAt: el.CreatedAt})
+		rows = append(rows, []string{el.Id, el.ExpireAt.Local().Format(dynamicSecretTimeFormat), el.CreatedAt.Local().Format(dynamicSecretTimeFormat)})
 	}
 
 	headers := []string{"ID", "Expire At", "Created At"}
 
-	GenericTable(headers, rows)
+	Render(output, func() { GenericTable(headers, rows) })
 }
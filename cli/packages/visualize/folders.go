@@ -2,13 +2,22 @@ package visualize
 
 import "github.com/Infisical/infisical-merge/packages/models"
 
+// FolderOutput is the JSON and YAML schema of a folder
+type FolderOutput struct {
+	Name string `json:"name" yaml:"name"`
+	Path string `json:"path" yaml:"path"`
+	ID   string `json:"id" yaml:"id"`
+}
+
 func PrintAllFoldersDetails(folders []models.SingleFolder, path string) {
+	output := []FolderOutput{}
 	rows := [][3]string{}
 	for _, folder := range folders {
+		output = append(output, FolderOutput{Name: folder.Name, Path: path, ID: folder.ID})
 		rows = append(rows, [...]string{folder.Name, path, folder.ID})
 	}
 
 	headers := [...]string{"FOLDER NAME", "PATH", "FOLDER ID"}
 
-	Table(headers, rows)
+	Render(output, func() { Table(headers, rows) })
 }
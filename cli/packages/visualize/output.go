@@ -0,0 +1,48 @@
+package visualize
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Infisical/infisical-merge/packages/config"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	OutputFormatTable = "table"
+	OutputFormatJson  = "json"
+	OutputFormatYaml  = "yaml"
+)
+
+var OutputFormats = []string{OutputFormatTable, OutputFormatJson, OutputFormatYaml}
+
+// IsStructuredOutput reports whether --output asks for JSON or YAML, in which case stdout only holds the rendered data
+func IsStructuredOutput() bool {
+	return config.OUTPUT_FORMAT == OutputFormatJson || config.OUTPUT_FORMAT == OutputFormatYaml
+}
+
+// Render prints data as JSON or YAML when --output asks for it, and otherwise calls printTable. The schemas come from the json
+// and yaml tags of data, which should name every field the same in both
+func Render(data any, printTable func()) {
+	var output []byte
+	var err error
+
+	switch config.OUTPUT_FORMAT {
+	case OutputFormatJson:
+		output, err = json.MarshalIndent(data, "", "  ")
+		output = append(output, '\n')
+	case OutputFormatYaml:
+		output, err = yaml.Marshal(data)
+	default:
+		printTable()
+		return
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: unable to render the output as %s [err=%v]\n", config.OUTPUT_FORMAT, err)
+		os.Exit(1)
+	}
+
+	os.Stdout.Write(output)
+}
@@ -2,13 +2,24 @@ package visualize
 
 import "github.com/Infisical/infisical-merge/packages/models"
 
+// SecretOutput is the JSON and YAML schema of a secret
+type SecretOutput struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+	Type  string `json:"type" yaml:"type"`
+	// the folder of the secret, empty for imported secrets
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+}
+
 func PrintAllSecretDetails(secrets []models.SingleEnvironmentVariable) {
+	output := []SecretOutput{}
 	rows := [][3]string{}
 	for _, secret := range secrets {
+		output = append(output, SecretOutput{Key: secret.Key, Value: secret.Value, Type: secret.Type, Path: secret.SecretPath})
 		rows = append(rows, [...]string{secret.Key, secret.Value, secret.Type})
 	}
 
 	headers := [...]string{"SECRET NAME", "SECRET VALUE", "SECRET TYPE"}
 
-	Table(headers, rows)
+	Render(output, func() { Table(headers, rows) })
 }
@@ -0,0 +1,3 @@
+module github.com/Infisical/infisical-merge/templatefuncs
+
+go 1.21
@@ -0,0 +1,128 @@
+// Package templatefuncs holds the helper functions of Go templates rendered with Infisical secrets. It is its own module without
+// dependencies, so the CLI and the Kubernetes operator share the same helpers
+package templatefuncs
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// FuncMap returns the helper functions available in every template: agent templates, `infisical export --template` and
+// the templates of InfisicalSecret resources.
+// The helpers follow the behaviour of the sprig functions of the same name
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"b64enc":   templateBase64Encode,
+		"b64dec":   templateBase64Decode,
+		"toJson":   templateToJson,
+		"fromJson": templateFromJson,
+		"default":  templateDefault,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+		"trim":     strings.TrimSpace,
+		"indent":   templateIndent,
+		"nindent":  templateNewlineIndent,
+		"quote":    templateQuote,
+		"squote":   templateSingleQuote,
+		"minus": func(a, b int) int {
+			return a - b
+		},
+		"add": func(a, b int) int {
+			return a + b
+		},
+	}
+}
+
+func templateBase64Encode(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
+
+func templateBase64Decode(value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("b64dec: %v", err)
+	}
+	return string(decoded), nil
+}
+
+func templateToJson(value interface{}) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("toJson: %v", err)
+	}
+	return string(encoded), nil
+}
+
+func templateFromJson(value string) (interface{}, error) {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(value), &decoded); err != nil {
+		return nil, fmt.Errorf("fromJson: %v", err)
+	}
+	return decoded, nil
+}
+
+// templateDefault returns the given value, or the default when the value is missing or empty, e.g. {{ .API_URL.Value | default "http://localhost" }}
+func templateDefault(defaultValue interface{}, given ...interface{}) interface{} {
+	if len(given) == 0 || isEmptyTemplateValue(given[0]) {
+		return defaultValue
+	}
+	return given[0]
+}
+
+func isEmptyTemplateValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return reflectValue.Len() == 0
+	case reflect.Bool:
+		return !reflectValue.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflectValue.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflectValue.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return reflectValue.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return reflectValue.IsNil()
+	default:
+		return false
+	}
+}
+
+// templateIndent indents every line of the value, e.g. to place a multi-line secret in a YAML block
+func templateIndent(spaces int, value string) string {
+	padding := strings.Repeat(" ", spaces)
+	return padding + strings.ReplaceAll(value, "\n", "\n"+padding)
+}
+
+func templateNewlineIndent(spaces int, value string) string {
+	return "\n" + templateIndent(spaces, value)
+}
+
+func templateQuote(values ...interface{}) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != nil {
+			quoted = append(quoted, fmt.Sprintf("%q", fmt.Sprint(value)))
+		}
+	}
+	return strings.Join(quoted, " ")
+}
+
+func templateSingleQuote(values ...interface{}) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != nil {
+			quoted = append(quoted, "'"+fmt.Sprint(value)+"'")
+		}
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,57 @@
+package templatefuncs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestFuncMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		data     interface{}
+		expected string
+	}{
+		{name: "b64enc", template: `{{ "hunter2" | b64enc }}`, expected: "aHVudGVyMg=="},
+		{name: "b64dec", template: `{{ "aHVudGVyMg==" | b64dec }}`, expected: "hunter2"},
+		{name: "toJson", template: `{{ toJson . }}`, data: map[string]string{"KEY": "va\"lue"}, expected: `{"KEY":"va\"lue"}`},
+		{name: "fromJson", template: `{{ (fromJson "{\"db\":{\"port\":5432}}").db.port }}`, expected: "5432"},
+		{name: "default for empty value", template: `{{ .Missing | default "fallback" }}`, data: map[string]string{}, expected: "fallback"},
+		{name: "default for set value", template: `{{ .Set | default "fallback" }}`, data: map[string]string{"Set": "value"}, expected: "value"},
+		{name: "upper", template: `{{ upper "db_host" }}`, expected: "DB_HOST"},
+		{name: "indent", template: `key:{{ nindent 2 "line1\nline2" }}`, expected: "key:\n  line1\n  line2"},
+		{name: "quote", template: `{{ quote "it's \"quoted\"" }}`, expected: `"it's \"quoted\""`},
+		{name: "squote", template: `{{ squote "value" }}`, expected: `'value'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New(tt.name).Funcs(FuncMap()).Parse(tt.template)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var output bytes.Buffer
+			if err := tmpl.Execute(&output, tt.data); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if output.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestFuncMapErrors(t *testing.T) {
+	tmpl, err := template.New("b64dec").Funcs(FuncMap()).Parse(`{{ b64dec "not base64!" }}`)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = tmpl.Execute(&bytes.Buffer{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "b64dec") {
+		t.Errorf("Expected a b64dec error, got %v", err)
+	}
+}
@@ -3,6 +3,7 @@ module github.com/Infisical/infisical/k8-operator
 go 1.21
 
 require (
+	github.com/Infisical/infisical-merge/templatefuncs v0.0.0
 	github.com/infisical/go-sdk v0.4.4
 	github.com/onsi/ginkgo/v2 v2.6.0
 	github.com/onsi/gomega v1.24.1
@@ -106,3 +107,6 @@ require (
 	sigs.k8s.io/structured-merge-diff/v4 v4.2.3 // indirect
 	sigs.k8s.io/yaml v1.3.0 // indirect
 )
+
+// the template helpers are shared with the CLI, see cli/templatefuncs
+replace github.com/Infisical/infisical-merge/templatefuncs => ../cli/templatefuncs